@@ -0,0 +1,44 @@
+package API
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// AuthError is returned when qBittorrent rejects the username and password,
+// or the API key.
+type AuthError struct {
+	Message string
+}
+
+func (e *AuthError) Error() string {
+	return "authentication error: " + e.Message
+}
+
+// BannedError is returned when qBittorrent refuses to log in, usually because
+// it banned the IP of the exporter after too many failed attempts.
+type BannedError struct {
+	StatusCode int
+}
+
+func (e *BannedError) Error() string {
+	return fmt.Sprintf("authentication failed, status code: %d. qBittorrent has probably banned your IP", e.StatusCode)
+}
+
+// UnreachableError is returned when qBittorrent can't be reached or times out.
+type UnreachableError struct {
+	Err error
+}
+
+func (e *UnreachableError) Error() string {
+	if errors.Is(e.Err, context.DeadlineExceeded) {
+		return QbittorrentTimeOut
+	}
+
+	return ErrorConnect + ": " + e.Err.Error()
+}
+
+func (e *UnreachableError) Unwrap() error {
+	return e.Err
+}
@@ -51,6 +51,7 @@ type MainData struct {
 	CategoryMap map[string]Category `json:"categories"`
 	ServerState ServerState         `json:"server_state"`
 	Tags        []string            `json:"tags"`
+	Trackers    map[string][]string `json:"trackers"`
 }
 
 type ServerState struct {
@@ -103,5 +104,129 @@ type DeltaMainData struct {
 	CategoriesRemoved []string                   `json:"categories_removed"`
 	Tags              []string                   `json:"tags"`
 	TagsRemoved       []string                   `json:"tags_removed"`
+	Trackers          map[string][]string        `json:"trackers"`
+	TrackersRemoved   []string                   `json:"trackers_removed"`
 	ServerState       json.RawMessage            `json:"server_state"`
 }
+
+// Peer is a peer of a torrent, as returned by sync/torrentPeers.
+type Peer struct {
+	Client      string  `json:"client"`
+	Connection  string  `json:"connection"`
+	Country     string  `json:"country"`
+	CountryCode string  `json:"country_code"`
+	DlSpeed     int64   `json:"dl_speed"`
+	Downloaded  int64   `json:"downloaded"`
+	Flags       string  `json:"flags"`
+	IP          string  `json:"ip"`
+	Port        int64   `json:"port"`
+	Progress    float64 `json:"progress"`
+	UpSpeed     int64   `json:"up_speed"`
+	Uploaded    int64   `json:"uploaded"`
+}
+
+// DeltaTorrentPeers represents the response from sync/torrentPeers with rid
+// parameter. Like DeltaMainData, peers use json.RawMessage so that partial
+// updates are merged into the existing Peer structs.
+type DeltaTorrentPeers struct {
+	Rid          int64                      `json:"rid"`
+	FullUpdate   bool                       `json:"full_update"`
+	Peers        map[string]json.RawMessage `json:"peers"`
+	PeersRemoved []string                   `json:"peers_removed"`
+}
+
+// File is a file of a torrent, as returned by torrents/files.
+type File struct {
+	Index        int64   `json:"index"`
+	Name         string  `json:"name"`
+	Size         int64   `json:"size"`
+	Progress     float64 `json:"progress"`
+	Priority     int64   `json:"priority"`
+	IsSeed       bool    `json:"is_seed"`
+	Availability float64 `json:"availability"`
+}
+
+// TransferInfo is the global transfer info, as returned by transfer/info.
+type TransferInfo struct {
+	ConnectionStatus string `json:"connection_status"`
+	DHTNodes         int64  `json:"dht_nodes"`
+	DlInfoData       int64  `json:"dl_info_data"`
+	DlInfoSpeed      int64  `json:"dl_info_speed"`
+	DlRateLimit      int64  `json:"dl_rate_limit"`
+	UpInfoData       int64  `json:"up_info_data"`
+	UpInfoSpeed      int64  `json:"up_info_speed"`
+	UpRateLimit      int64  `json:"up_rate_limit"`
+}
+
+// Properties are the generic properties of a torrent, as returned by
+// torrents/properties. The share limits are only sent by recent versions.
+type Properties struct {
+	CreationDate       int64    `json:"creation_date"`
+	LastSeen           int64    `json:"last_seen"`
+	NbConnections      int64    `json:"nb_connections"`
+	NbConnectionsLimit int64    `json:"nb_connections_limit"`
+	PieceSize          int64    `json:"piece_size"`
+	PiecesHave         int64    `json:"pieces_have"`
+	PiecesNum          int64    `json:"pieces_num"`
+	Reannounce         int64    `json:"reannounce"`
+	SeedingTime        int64    `json:"seeding_time"`
+	TotalWasted        int64    `json:"total_wasted"`
+	RatioLimit         *float64 `json:"ratio_limit"`
+	SeedingTimeLimit   *int64   `json:"seeding_time_limit"`
+}
+
+// Log types of the log/main entries.
+const (
+	LogTypeNormal   int64 = 1
+	LogTypeInfo     int64 = 2
+	LogTypeWarning  int64 = 4
+	LogTypeCritical int64 = 8
+)
+
+// LogEntry is an entry of the main log, as returned by log/main.
+type LogEntry struct {
+	ID        int64  `json:"id"`
+	Message   string `json:"message"`
+	Timestamp int64  `json:"timestamp"`
+	Type      int64  `json:"type"`
+}
+
+// PeerLogEntry is an entry of the peer log, as returned by log/peers.
+type PeerLogEntry struct {
+	ID        int64  `json:"id"`
+	IP        string `json:"ip"`
+	Timestamp int64  `json:"timestamp"`
+	Blocked   bool   `json:"blocked"`
+	Reason    string `json:"reason"`
+}
+
+// RSSArticle is an article of an RSS feed.
+type RSSArticle struct {
+	ID     string `json:"id"`
+	Date   string `json:"date"`
+	Title  string `json:"title"`
+	IsRead bool   `json:"isRead"`
+}
+
+// RSSFeed is an RSS feed, as returned by rss/items with its data. Feeds are
+// nested in folders, see RSSItems.
+type RSSFeed struct {
+	UID           string       `json:"uid"`
+	URL           string       `json:"url"`
+	Title         string       `json:"title"`
+	LastBuildDate string       `json:"lastBuildDate"`
+	IsLoading     bool         `json:"isLoading"`
+	HasError      bool         `json:"hasError"`
+	Articles      []RSSArticle `json:"articles"`
+}
+
+// RSSItems is a folder of RSS feeds and folders, by name, as returned by
+// rss/items.
+type RSSItems map[string]json.RawMessage
+
+// RSSRule is an RSS auto-downloading rule, as returned by rss/rules.
+type RSSRule struct {
+	Enabled       bool     `json:"enabled"`
+	AffectedFeeds []string `json:"affectedFeeds"`
+	LastMatch     string   `json:"lastMatch"`
+}
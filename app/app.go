@@ -3,11 +3,15 @@ package app
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"flag"
 	"fmt"
+	"maps"
 	"net"
 	"net/http"
 	"os"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -28,6 +32,14 @@ var (
 	QBittorrent QBittorrentSettings
 	Exporter    ExporterSettings
 	HttpClient  http.Client
+
+	// Instances lists every qBittorrent instance scraped by the exporter.
+	// Without QBITTORRENT_INSTANCES, it only contains &QBittorrent.
+	Instances []*QBittorrentSettings
+
+	// ProbeModules holds the credentials used by the probe endpoint, by module
+	// name. The default module has an empty name.
+	ProbeModules map[string]*QBittorrentSettings
 )
 
 type ExporterSettings struct {
@@ -38,6 +50,94 @@ type ExporterSettings struct {
 	Features             Features
 	Path                 string
 	BasicAuth            *BasicAuth
+	// PollInterval enables the background collection of the metrics when
+	// greater than zero. Scrapes then serve the latest snapshot.
+	PollInterval time.Duration
+	// PollMaxAge is the age after which a snapshot is no longer served.
+	PollMaxAge time.Duration
+	Peers      PeersSettings
+	Files      FilesSettings
+	Properties PropertiesSettings
+	// TrackerURLLabel is how tracker URLs are written in label values:
+	// TrackerURLRedacted, TrackerURLHost or TrackerURLRaw.
+	TrackerURLLabel string
+	// Relabel rewrites the series before they are exported, nil without
+	// relabel config.
+	Relabel *RelabelConfig
+	// TorrentFilter selects the torrents getting per-torrent series.
+	TorrentFilter TorrentFilter
+	TopTorrents   TopTorrentsSettings
+	// ProcessMetricsPath serves the process and Go runtime metrics of the
+	// exporter, empty when not served on a separate path.
+	ProcessMetricsPath string
+}
+
+type TopTorrentsSettings struct {
+	// Count is the number of torrents getting per-torrent series, the others
+	// are summed in an "other" series. 0 disables the top torrents mode.
+	Count int
+	// By is the key ranking the torrents, one of the TopBy constants.
+	By string
+}
+
+type PeersSettings struct {
+	// Torrents are the names or hashes of the torrents whose peers are
+	// collected. When empty, the torrents currently transferring are used.
+	Torrents []string
+	// MaxGroups is the max number of client/country/connection series per
+	// torrent. Other peers are counted in an "other" series.
+	MaxGroups int
+	// MaxTorrents is the max number of torrents whose peers are collected on
+	// each scrape.
+	MaxTorrents int
+}
+
+type FilesSettings struct {
+	// Torrents are the names or hashes of the torrents whose files are
+	// collected. When empty, the incomplete torrents are used.
+	Torrents []string
+	// MaxPerTorrent is the max number of files exported per torrent.
+	MaxPerTorrent int
+	// MaxTorrents is the max number of torrents whose files are collected on
+	// each scrape.
+	MaxTorrents int
+}
+
+type PropertiesSettings struct {
+	// Torrents are the names or hashes of the torrents whose properties are
+	// collected. When empty, every torrent is used.
+	Torrents []string
+	// MaxTorrents is the max number of torrents whose properties are
+	// collected on each scrape.
+	MaxTorrents int
+}
+
+// TorrentFilter decides which torrents get per-torrent series. A torrent is
+// exported when it matches Include, or Include is empty, and doesn't match
+// Exclude.
+type TorrentFilter struct {
+	Include TorrentMatcher
+	Exclude TorrentMatcher
+}
+
+// TorrentMatcher lists the values matched by a TorrentFilter. A torrent
+// matches Include when it matches every non-empty field, and Exclude when it
+// matches any of them.
+type TorrentMatcher struct {
+	Categories []string
+	Tags       []string
+	States     []string
+	// TrackerHosts are the hosts of the trackers, without port.
+	TrackerHosts []string
+	// SavePaths are prefixes of the save paths.
+	SavePaths []string
+	Name      *regexp.Regexp
+}
+
+// IsEmpty tells whether no field of the matcher is set.
+func (m *TorrentMatcher) IsEmpty() bool {
+	return len(m.Categories) == 0 && len(m.Tags) == 0 && len(m.States) == 0 &&
+		len(m.TrackerHosts) == 0 && len(m.SavePaths) == 0 && m.Name == nil
 }
 
 type BasicAuth struct {
@@ -46,6 +146,10 @@ type BasicAuth struct {
 }
 
 type QBittorrentSettings struct {
+	// Name is the value of the instance label. It is empty when a single
+	// instance is configured without QBITTORRENT_INSTANCES.
+	Name string
+
 	Timeout time.Duration
 	BaseUrl string
 
@@ -55,6 +159,13 @@ type QBittorrentSettings struct {
 
 	// BasicAuth sets the Authorization header for requests to BaseUrl.
 	BasicAuth *BasicAuth
+
+	// HttpClient is used for requests to BaseUrl, HttpClient is used when nil.
+	HttpClient *http.Client
+
+	// ProbeTargets are the base URLs the probe endpoint accepts as target for
+	// this module. Only set for probe modules.
+	ProbeTargets []string
 }
 
 type LegacyAuth struct {
@@ -78,11 +189,35 @@ type Features struct {
 	EnableIncreasedCardinality bool
 	EnableHighCardinality      bool
 	EnableTracker              bool
+	EnableTrackerTorrents      bool
+	EnableProbe                bool
+	EnableAggregates           bool
+	EnablePeers                bool
+	EnableFiles                bool
+	EnableProperties           bool
+	EnableLog                  bool
+	EnableRSS                  bool
+	EnableLegacyGauges         bool
+	EnableProcessMetrics       bool
 	ShowPassword               bool
 }
 
-func LoadEnv() {
+// Client returns the HTTP client used to reach this instance.
+func (s *QBittorrentSettings) Client() *http.Client {
+	if s.HttpClient != nil {
+		return s.HttpClient
+	}
+
+	return &HttpClient
+}
+
+// LoadEnv reads the command line options and the settings. It returns true
+// when --check-config only asks to check them. Invalid settings are returned
+// as ConfigError.
+func LoadEnv() (bool, error) {
 	envfile := flag.Bool("e", false, "Use .env file")
+	configFile := flag.String("config", "", "Path of the YAML or TOML config file")
+	checkConfig := flag.Bool("check-config", false, "Check the configuration and exit")
 
 	flag.Parse()
 
@@ -91,100 +226,144 @@ func LoadEnv() {
 	if _, err := os.Stat(".env"); err == nil && !*envfile { //nolint:noinlineerr
 		err := godotenv.Load(".env")
 		if err != nil {
-			errormessage := "Error loading .env file:" + err.Error()
-			panic(errormessage)
+			return false, &ConfigError{Path: ".env", Message: "error loading the .env file: " + err.Error()}
 		}
 
 		envFileMessage = "Using .env file"
 	}
 
-	defaultLogLevelEnv, _ := getEnv(defaultLogLevel)
-	loglevel := logger.SetLogLevel(defaultLogLevelEnv)
+	if *configFile != "" {
+		values, err := loadConfigFile(*configFile)
+		if err != nil {
+			return false, err
+		}
+
+		configFilePath = *configFile
+		configValues = values
+		envFileMessage += " and " + *configFile
+	}
+
+	printBanner()
+
+	loaded, err := loadSettings(envFileMessage)
+	if err != nil {
+		return *checkConfig, err
+	}
+
+	loaded.apply()
+
+	return *checkConfig, nil
+}
+
+// printBanner prints the version and the log level on startup, the log level
+// is read again by loadSettings.
+func printBanner() {
+	logLevelEnv, _ := getEnv(defaultLogLevel)
+	loglevel := logger.SetLogLevel(logLevelEnv)
 
 	fmt.Printf("%s (version %s)\n", projectName, version)
 	fmt.Printf("Using log level: %s%s%s\n", logger.ColorLogLevel[logger.LogLevels[loglevel]], loglevel, logger.Reset)
+}
 
-	apiKey := getOptionalEnv(defaultAPIKey)
+// loadSettings reads the settings from the environment variables, and from
+// the config file values. The current settings are left as is, the returned
+// ones only take effect with apply.
+func loadSettings(envFileMessage string) (settings, error) {
+	var loaded settings
 
-	showPassword := false
+	defaultLogLevelEnv, _ := getEnv(defaultLogLevel)
+	loglevel := logger.ParseLogLevel(defaultLogLevelEnv)
 
-	var legacyAuth LegacyAuth
+	showPasswordString, _ := getEnv(defaultExporterShowPassword)
+	showPassword := envSetToTrue(showPasswordString)
 
-	if apiKey == nil {
-		cookieName, _ := getEnv(defaultCookieName)
+	// Every invalid setting is reported at once
+	var errs []error
 
-		qbitUsername, usingDefaultValue := getEnv(defaultUsername)
-		if !usingDefaultValue {
-			logger.Info("username: " + qbitUsername)
+	instanceNames, err := getInstanceNames()
+
+	switch {
+	case err != nil:
+		errs = append(errs, err)
+	case len(instanceNames) == 0:
+		loaded.qbittorrent, err = loadQBittorrentSettings("", showPassword, true)
+		if err != nil {
+			errs = append(errs, err)
+
+			break
 		}
 
-		showPasswordString, _ := getEnv(defaultExporterShowPassword)
-		showPassword = envSetToTrue(showPasswordString)
-		qbitPassword, usingDefaultValue := getPassword()
-		// When using the default value it is logged already
-		if !usingDefaultValue {
-			password := GetPasswordMasked(qbitPassword)
-			if showPassword {
-				password = qbitPassword
+		// apply points the instance to HttpClient and lists it in Instances
+		loaded.httpClient = *loaded.qbittorrent.HttpClient
+		loaded.qbittorrent.HttpClient = nil
+	default:
+		loaded.instances = make([]*QBittorrentSettings, 0, len(instanceNames))
+		for _, name := range instanceNames {
+			settings, err := loadQBittorrentSettings(name, showPassword, true)
+			if err != nil {
+				errs = append(errs, err)
+
+				continue
 			}
 
-			logger.Info("password: " + password)
+			loaded.instances = append(loaded.instances, &settings)
 		}
 
-		legacyAuth = LegacyAuth{
-			Username: qbitUsername,
-			Password: qbitPassword,
-			Cookie: Cookie{
-				Key:   cookieName,
-				Value: nil,
-			},
+		if len(loaded.instances) > 0 {
+			loaded.qbittorrent = *loaded.instances[0]
 		}
 	}
 
-	baseUrlEnv, usingDefaultValue := getEnv(defaultBaseUrl)
+	enableProbe, _ := getEnv(defaultEnableProbe)
 
-	baseUrl := strings.TrimSuffix(baseUrlEnv, "/")
-	if !internal.IsValidURL(baseUrl) {
-		panic(fmt.Sprintf("%s is not a valid URL (check %s)", baseUrl, defaultBaseUrl.Key))
-	}
-
-	if !usingDefaultValue {
-		logger.Info("qBittorrent URL: " + baseUrl)
-	}
-
-	qbitBasicAuthUsername := getOptionalEnv(defaultQbitBasicAuthUsername)
-	qbitBasicAuthPassword := getOptionalEnv(defaultQbitBasicAuthPassword)
 	exporterPortEnv, _ := getEnv(defaultPort)
 	exporterHostEnv, _ := getEnv(defaultHost)
-	timeoutDurationEnv, _ := getEnv(defaultTimeout)
-	fullRefreshIntervalEnv, _ := getEnv(defaultFullRefreshInterval)
 	enableTracker, _ := getEnv(defaultEnableTracker)
 	labelWithTracker, _ := getEnv(defaultLabelWithTracker)
 	labelWithTag, _ := getEnv(defaultLabelWithTag)
 	enableHighCardinality, _ := getEnv(defaultHighCardinality)
 	enableIncreasedCardinality, _ := getEnv(defaultIncreasedCardinality)
 	labelWithHash, _ := getEnv(defaultLabelWithHash)
+	legacyGauges, _ := getEnv(defaultLegacyGauges)
 	exporterUrlEnv := getOptionalEnv(defaultExporterURL)
 	exporterPath, _ := getEnv(defaultExporterPathEnv)
+	pollIntervalEnv, _ := getEnv(defaultPollInterval)
+	pollMaxAgeEnv := getOptionalEnv(defaultPollMaxAge)
+	trackerURLLabelEnv, _ := getEnv(defaultTrackerURLLabel)
+	relabelConfigFileEnv := getOptionalEnv(defaultRelabelConfigFile)
+	torrentsTopEnv, _ := getEnv(defaultTorrentsTop)
+	torrentsTopByEnv, _ := getEnv(defaultTorrentsTopBy)
+	enableTrackerTorrents, _ := getEnv(defaultEnableTrackerTorrents)
+	enableAggregates, _ := getEnv(defaultEnableAggregates)
+	enablePeers, _ := getEnv(defaultEnablePeers)
+	peersTorrentsEnv := getOptionalEnv(defaultPeersTorrents)
+	peersMaxGroupsEnv, _ := getEnv(defaultPeersMaxGroups)
+	peersMaxTorrentsEnv, _ := getEnv(defaultPeersMaxTorrents)
+	enableFiles, _ := getEnv(defaultEnableFiles)
+	filesTorrentsEnv := getOptionalEnv(defaultFilesTorrents)
+	filesMaxPerTorrentEnv, _ := getEnv(defaultFilesMaxPerTorrent)
+	filesMaxTorrentsEnv, _ := getEnv(defaultFilesMaxTorrents)
+	enableProperties, _ := getEnv(defaultEnableProperties)
+	propertiesTorrentsEnv := getOptionalEnv(defaultPropertiesTorrents)
+	propertiesMaxTorrentsEnv, _ := getEnv(defaultPropertiesMaxTorrents)
+	enableLog, _ := getEnv(defaultEnableLog)
+	enableRSS, _ := getEnv(defaultEnableRSS)
+	enableProcessMetrics, _ := getEnv(defaultEnableProcessMetrics)
+	processMetricsPathEnv := getOptionalEnv(defaultProcessMetricsPath)
 
 	basicAuthUsername := getOptionalEnv(defaultBasicAuthUsername)
 	basicAuthPassword := getOptionalEnv(defaultBasicAuthPassword)
-	certificateAuthorityPath := getOptionalEnv(defaultCertificateAuthorityPath)
-	insecureSkipVerify, _ := getEnv(defaultInsecureSkipVerify)
-	minTlsVersionStr, _ := getEnv(defaultMinTlsVersion)
 
 	logger.Debug(envFileMessage)
 
 	exporterPort, errExporterPort := strconv.Atoi(exporterPortEnv)
-	if errExporterPort != nil {
-		panic(fmt.Sprintf("%s must be an integer (check %s)", exporterPortEnv, defaultPort.Key))
-	}
-
-	if exporterPort < 0 || exporterPort > 65353 {
-		panic(fmt.Sprintf("%d must be > 0 and < 65353 (check %s)", exporterPort, defaultPort.Key))
-	}
 
-	if exporterPort != defaultExporterPort {
+	switch {
+	case errExporterPort != nil:
+		errs = append(errs, configError(defaultPort.Key, "%s must be an integer", exporterPortEnv))
+	case exporterPort < 0 || exporterPort > maxExporterPort:
+		errs = append(errs, configError(defaultPort.Key, "%d must be >= 0 and <= %d", exporterPort, maxExporterPort))
+	case exporterPort != defaultExporterPort:
 		logger.Info(fmt.Sprintf("Listening on port %d", exporterPort))
 	}
 
@@ -192,24 +371,6 @@ func LoadEnv() {
 		logger.Info("Binding to host " + exporterHostEnv)
 	}
 
-	timeoutDuration, errTimeoutDuration := strconv.Atoi(timeoutDurationEnv)
-	if errTimeoutDuration != nil {
-		panic(fmt.Sprintf("%s must be an integer (check %s)", timeoutDurationEnv, defaultTimeout.Key))
-	}
-
-	if timeoutDuration < 0 {
-		panic(fmt.Sprintf("%d must be > 0 (check %s)", timeoutDuration, defaultTimeout.Key))
-	}
-
-	fullRefreshInterval, errFullRefreshInterval := strconv.Atoi(fullRefreshIntervalEnv)
-	if errFullRefreshInterval != nil {
-		panic(fmt.Sprintf("%s must be an integer (check %s)", fullRefreshIntervalEnv, defaultFullRefreshInterval.Key))
-	}
-
-	if fullRefreshInterval < 1 {
-		panic(fmt.Sprintf("%d must be >= 1 (check %s)", fullRefreshInterval, defaultFullRefreshInterval.Key))
-	}
-
 	exporterUrl := ""
 
 	if version == devVersion {
@@ -225,7 +386,8 @@ func LoadEnv() {
 	if exporterUrlEnv != nil {
 		exporterUrl = strings.TrimSuffix(*exporterUrlEnv, "/")
 		if !internal.IsValidURL(exporterUrl) {
-			panic(fmt.Sprintf("%s is not a valid URL (check %s)", exporterUrl, defaultExporterURL))
+			errs = append(errs, configError(defaultExporterURL, "%s is not a valid URL", exporterUrl))
+			exporterUrl = ""
 		}
 	}
 
@@ -233,37 +395,452 @@ func LoadEnv() {
 		logger.Info("qbittorrent-exporter URL: " + exporterUrl)
 	}
 
+	exporterBasicAuth := getBasicAuth(basicAuthUsername, basicAuthPassword, defaultBasicAuthUsername, defaultBasicAuthPassword)
+
+	if exporterBasicAuth != nil {
+		logger.Info("Using basic auth to protect the exporter instance")
+	} else {
+		logger.Trace("Not using basic auth to protect the exporter instance")
+	}
+
+	if envSetToTrue(enableProbe) {
+		// The probe endpoint sends the credentials of its modules to the targets
+		if exporterBasicAuth == nil || exporterBasicAuth.Password == "" {
+			errs = append(errs, configError(defaultEnableProbe.Key, "the probe endpoint requires basic auth (check %s and %s)",
+				defaultBasicAuthUsername, defaultBasicAuthPassword))
+		} else {
+			loaded.probeModules, err = loadProbeModules(len(instanceNames) == 0, showPassword)
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	// The password is only shown in the logs when using the legacy auth
+	usingLegacyAuth := false
+
+	if len(instanceNames) == 0 && loaded.qbittorrent.APIKey == nil {
+		usingLegacyAuth = true
+	}
+
+	for _, instance := range loaded.instances {
+		if instance.APIKey == nil {
+			usingLegacyAuth = true
+		}
+	}
+
+	for _, module := range loaded.probeModules {
+		if module.APIKey == nil {
+			usingLegacyAuth = true
+		}
+	}
+
+	internal.EnsureLeadingSlash(&exporterPath)
+
+	processMetricsPath := ""
+	if processMetricsPathEnv != nil && *processMetricsPathEnv != "" {
+		processMetricsPath = *processMetricsPathEnv
+		internal.EnsureLeadingSlash(&processMetricsPath)
+
+		if processMetricsPath == exporterPath {
+			errs = append(errs, configError(defaultProcessMetricsPath, "%s is already the metrics path", processMetricsPath))
+		}
+	}
+
+	pollInterval, pollMaxAge, errPoll := getPollSettings(pollIntervalEnv, pollMaxAgeEnv)
+	peers, errPeers := getPeersSettings(peersTorrentsEnv, peersMaxGroupsEnv, peersMaxTorrentsEnv)
+	files, errFiles := getFilesSettings(filesTorrentsEnv, filesMaxPerTorrentEnv, filesMaxTorrentsEnv)
+	propertiesMaxTorrents, errProperties := getPositiveInt(propertiesMaxTorrentsEnv, defaultPropertiesMaxTorrents.Key)
+	trackerURLLabel, errTrackerURLLabel := getTrackerURLLabel(trackerURLLabelEnv)
+	relabel, errRelabel := loadRelabelConfig(relabelConfigFileEnv)
+	include, errInclude := getTorrentMatcher(defaultTorrentsInclude)
+	exclude, errExclude := getTorrentMatcher(defaultTorrentsExclude)
+	topTorrents, errTopTorrents := getTopTorrentsSettings(torrentsTopEnv, torrentsTopByEnv)
+
+	errs = append(errs, errPoll, errPeers, errFiles, errProperties, errTrackerURLLabel, errRelabel, errInclude, errExclude, errTopTorrents)
+
+	err = errors.Join(errs...)
+	if err != nil {
+		return settings{}, err //nolint:exhaustruct
+	}
+
+	loaded.exporter = ExporterSettings{
+		Features: Features{
+			EnableIncreasedCardinality: envSetToTrue(enableIncreasedCardinality),
+			EnableHighCardinality:      envSetToTrue(enableHighCardinality),
+			EnableTracker:              envSetToTrue(enableTracker),
+			EnableTrackerTorrents:      envSetToTrue(enableTrackerTorrents),
+			EnableProbe:                envSetToTrue(enableProbe),
+			EnableAggregates:           envSetToTrue(enableAggregates),
+			EnablePeers:                envSetToTrue(enablePeers),
+			EnableFiles:                envSetToTrue(enableFiles),
+			EnableProperties:           envSetToTrue(enableProperties),
+			EnableLog:                  envSetToTrue(enableLog),
+			EnableRSS:                  envSetToTrue(enableRSS),
+			EnableLegacyGauges:         envSetToTrue(legacyGauges),
+			EnableProcessMetrics:       envSetToTrue(enableProcessMetrics),
+			ShowPassword:               showPassword && usingLegacyAuth,
+		},
+		ExperimentalFeatures: ExperimentalFeatures{
+			EnableLabelWithHash:    envSetToTrue(labelWithHash),
+			EnableLabelWithTracker: envSetToTrue(labelWithTracker),
+			EnableLabelWithTags:    envSetToTrue(labelWithTag),
+		},
+		LogLevel:  loglevel,
+		Port:      exporterPort,
+		Host:      exporterHostEnv,
+		Path:      exporterPath,
+		BasicAuth: exporterBasicAuth,
+
+		PollInterval: pollInterval,
+		PollMaxAge:   pollMaxAge,
+		Peers:        peers,
+		Files:        files,
+		Properties: PropertiesSettings{
+			Torrents:    getList(propertiesTorrentsEnv),
+			MaxTorrents: propertiesMaxTorrents,
+		},
+
+		TrackerURLLabel: trackerURLLabel,
+		Relabel:         relabel,
+		TorrentFilter: TorrentFilter{
+			Include: include,
+			Exclude: exclude,
+		},
+		TopTorrents:        topTorrents,
+		ProcessMetricsPath: processMetricsPath,
+	}
+
+	return loaded, nil
+}
+
+// getPeersSettings parses the torrents whose peers are collected and the
+// cardinality caps of the peers metrics.
+func getPeersSettings(torrentsEnv *string, maxGroupsEnv string, maxTorrentsEnv string) (PeersSettings, error) {
+	maxGroups, errMaxGroups := getPositiveInt(maxGroupsEnv, defaultPeersMaxGroups.Key)
+	maxTorrents, errMaxTorrents := getPositiveInt(maxTorrentsEnv, defaultPeersMaxTorrents.Key)
+
+	return PeersSettings{
+		Torrents:    getList(torrentsEnv),
+		MaxGroups:   maxGroups,
+		MaxTorrents: maxTorrents,
+	}, errors.Join(errMaxGroups, errMaxTorrents)
+}
+
+// getFilesSettings parses the torrents whose files are collected, the max
+// number of these torrents and the max number of files exported per torrent.
+func getFilesSettings(torrentsEnv *string, maxPerTorrentEnv string, maxTorrentsEnv string) (FilesSettings, error) {
+	maxPerTorrent, errMaxPerTorrent := getPositiveInt(maxPerTorrentEnv, defaultFilesMaxPerTorrent.Key)
+	maxTorrents, errMaxTorrents := getPositiveInt(maxTorrentsEnv, defaultFilesMaxTorrents.Key)
+
+	return FilesSettings{
+		Torrents:      getList(torrentsEnv),
+		MaxPerTorrent: maxPerTorrent,
+		MaxTorrents:   maxTorrents,
+	}, errors.Join(errMaxPerTorrent, errMaxTorrents)
+}
+
+// getList returns the non-empty values of a comma separated list.
+func getList(env *string) []string {
+	if env == nil {
+		return nil
+	}
+
+	var values []string
+
+	for value := range strings.SplitSeq(*env, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+func getPositiveInt(value string, key string) (int, error) {
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, configError(key, "%s must be an integer", value)
+	}
+
+	if number < 1 {
+		return 0, configError(key, "%d must be > 0", number)
+	}
+
+	return number, nil
+}
+
+// getTorrentMatcher parses the torrent filter variables starting with prefix.
+func getTorrentMatcher(prefix string) (TorrentMatcher, error) {
+	matcher := TorrentMatcher{
+		Categories:   getList(getOptionalEnv(prefix + "CATEGORIES")),
+		Tags:         getList(getOptionalEnv(prefix + "TAGS")),
+		States:       getList(getOptionalEnv(prefix + "STATES")),
+		TrackerHosts: getList(getOptionalEnv(prefix + "TRACKERS")),
+		SavePaths:    getList(getOptionalEnv(prefix + "SAVE_PATHS")),
+		Name:         nil,
+	}
+
+	for i, host := range matcher.TrackerHosts {
+		matcher.TrackerHosts[i] = strings.ToLower(host)
+	}
+
+	if name := getOptionalEnv(prefix + "NAME"); name != nil && *name != "" {
+		regex, err := regexp.Compile(*name)
+		if err != nil {
+			return matcher, configError(prefix+"NAME", "invalid regular expression %s: %s", *name, err)
+		}
+
+		matcher.Name = regex
+	}
+
+	return matcher, nil
+}
+
+// getTopTorrentsSettings parses the number of torrents getting per-torrent
+// series and the key ranking them.
+func getTopTorrentsSettings(countEnv, by string) (TopTorrentsSettings, error) {
+	count, err := strconv.Atoi(countEnv)
+	if err != nil || count < 0 {
+		return TopTorrentsSettings{}, configError(defaultTorrentsTop.Key, "%s must be an integer >= 0", countEnv) //nolint:exhaustruct
+	}
+
+	switch by {
+	case TopByUploadSpeed, TopByDownloadSpeed, TopByRatio, TopBySize, TopByAddedOn:
+	default:
+		return TopTorrentsSettings{}, configError(defaultTorrentsTopBy.Key, //nolint:exhaustruct
+			"invalid top torrents key: %s (valid options are %s, %s, %s, %s and %s)",
+			by, TopByUploadSpeed, TopByDownloadSpeed, TopByRatio, TopBySize, TopByAddedOn)
+	}
+
+	return TopTorrentsSettings{Count: count, By: by}, nil
+}
+
+// getTrackerURLLabel validates how tracker URLs are written in label values.
+func getTrackerURLLabel(value string) (string, error) {
+	switch value {
+	case TrackerURLRedacted, TrackerURLHost:
+		return value, nil
+	case TrackerURLRaw:
+		logger.Warn("Tracker URLs are exported as is, with their passkeys")
+
+		return value, nil
+	default:
+		return "", configError(defaultTrackerURLLabel.Key, "invalid tracker URL label: %s (valid options are %s, %s and %s)",
+			value, TrackerURLRedacted, TrackerURLHost, TrackerURLRaw)
+	}
+}
+
+// getPollSettings parses the polling interval and the max age of the
+// snapshots, in seconds. The max age defaults to 3 polling intervals.
+func getPollSettings(pollIntervalEnv string, pollMaxAgeEnv *string) (time.Duration, time.Duration, error) {
+	pollInterval, err := strconv.Atoi(pollIntervalEnv)
+	if err != nil {
+		return 0, 0, configError(defaultPollInterval.Key, "%s must be an integer", pollIntervalEnv)
+	}
+
+	if pollInterval < 0 {
+		return 0, 0, configError(defaultPollInterval.Key, "%d must be >= 0", pollInterval)
+	}
+
+	if pollInterval == 0 {
+		return 0, 0, nil
+	}
+
+	pollMaxAge := pollInterval * defaultPollMaxAgeIntervals
+
+	if pollMaxAgeEnv != nil && *pollMaxAgeEnv != "" {
+		pollMaxAge, err = strconv.Atoi(*pollMaxAgeEnv)
+		if err != nil {
+			return 0, 0, configError(defaultPollMaxAge, "%s must be an integer", *pollMaxAgeEnv)
+		}
+
+		if pollMaxAge < pollInterval {
+			return 0, 0, configError(defaultPollMaxAge, "%d must be >= %d", pollMaxAge, pollInterval)
+		}
+	}
+
+	logger.Info(fmt.Sprintf("Polling qBittorrent every %ds (max age %ds)", pollInterval, pollMaxAge))
+
+	return time.Duration(pollInterval) * time.Second, time.Duration(pollMaxAge) * time.Second, nil
+}
+
+// loadProbeModules reads the credentials of the modules listed in
+// PROBE_MODULES. The default module uses the unprefixed environment variables.
+func loadProbeModules(singleInstance bool, showPassword bool) (map[string]*QBittorrentSettings, error) {
+	modules := make(map[string]*QBittorrentSettings)
+
+	var errs []error
+
+	if singleInstance {
+		modules[""] = &QBittorrent
+	} else {
+		settings, err := loadQBittorrentSettings("", showPassword, false)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			modules[""] = &settings
+		}
+	}
+
+	names, err := getNames(defaultProbeModules)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	for _, name := range names {
+		settings, err := loadQBittorrentSettings(name, showPassword, false)
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		modules[name] = &settings
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(modules)) {
+		modules[name].ProbeTargets, err = getProbeTargets(name)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	err = errors.Join(errs...)
+	if err != nil {
+		return nil, err
+	}
+
+	return modules, nil
+}
+
+// getProbeTargets returns the base URLs of the targets allowed for the probe
+// module. Since the credentials of the module are sent to the target, the
+// list can't be empty.
+func getProbeTargets(module string) ([]string, error) {
+	key := instanceEnvKey(module, defaultProbeTargets)
+
+	targets := getList(getInstanceOptionalEnv(module, defaultProbeTargets))
+	if len(targets) == 0 {
+		return nil, configError(key, "the targets of the probe module must be listed")
+	}
+
+	for i, target := range targets {
+		baseUrl, valid := internal.NormalizeBaseURL(target)
+		if !valid {
+			return nil, configError(key, "%s is not a valid URL", target)
+		}
+
+		targets[i] = baseUrl
+	}
+
+	return targets, nil
+}
+
+// loadQBittorrentSettings reads the settings of a qBittorrent instance.
+// An empty name reads the unprefixed environment variables only.
+// With requireBaseUrl, a named instance must set its own base URL.
+func loadQBittorrentSettings(name string, showPassword bool, requireBaseUrl bool) (QBittorrentSettings, error) {
+	logPrefix := ""
+	if name != "" {
+		logPrefix = fmt.Sprintf("[%s] ", name)
+	}
+
+	var errs []error
+
+	apiKey := getInstanceOptionalEnv(name, defaultAPIKey)
+
+	var legacyAuth LegacyAuth
+
+	if apiKey == nil {
+		cookieName, _ := getInstanceEnv(name, defaultCookieName)
+
+		qbitUsername, usingDefaultValue := getInstanceEnv(name, defaultUsername)
+		if !usingDefaultValue {
+			logger.Info(logPrefix + "username: " + qbitUsername)
+		}
+
+		qbitPassword, usingDefaultValue, err := getPassword(name)
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		// When using the default value it is logged already
+		if err == nil && !usingDefaultValue {
+			password := GetPasswordMasked(qbitPassword)
+			if showPassword {
+				password = qbitPassword
+			}
+
+			logger.Info(logPrefix + "password: " + password)
+		}
+
+		legacyAuth = LegacyAuth{
+			Username: qbitUsername,
+			Password: qbitPassword,
+			Cookie: Cookie{
+				Key:   cookieName,
+				Value: nil,
+			},
+		}
+	}
+
+	baseUrlKey := instanceEnvKey(name, defaultBaseUrl.Key)
+	baseUrlEnv, usingDefaultValue := getInstanceEnv(name, defaultBaseUrl)
+	baseUrl := strings.TrimSuffix(baseUrlEnv, "/")
+
+	switch {
+	case requireBaseUrl && name != "" && getOptionalEnv(baseUrlKey) == nil:
+		errs = append(errs, configError(baseUrlKey, "qBittorrent URL of instance %s is not set", name))
+	case !internal.IsValidURL(baseUrl):
+		errs = append(errs, configError(baseUrlKey, "%s is not a valid URL", baseUrl))
+	case !usingDefaultValue:
+		logger.Info(logPrefix + "qBittorrent URL: " + baseUrl)
+	}
+
+	qbitBasicAuthUsername := getInstanceOptionalEnv(name, defaultQbitBasicAuthUsername)
+	qbitBasicAuthPassword := getInstanceOptionalEnv(name, defaultQbitBasicAuthPassword)
+	timeoutDurationEnv, _ := getInstanceEnv(name, defaultTimeout)
+	fullRefreshIntervalEnv, _ := getInstanceEnv(name, defaultFullRefreshInterval)
+	certificateAuthorityPath := getInstanceOptionalEnv(name, defaultCertificateAuthorityPath)
+	insecureSkipVerify, _ := getInstanceEnv(name, defaultInsecureSkipVerify)
+	minTlsVersionStr, _ := getInstanceEnv(name, defaultMinTlsVersion)
+
+	timeoutDuration, errTimeoutDuration := strconv.Atoi(timeoutDurationEnv)
+	if errTimeoutDuration != nil {
+		errs = append(errs, configError(defaultTimeout.Key, "%s must be an integer", timeoutDurationEnv))
+	} else if timeoutDuration < 0 {
+		errs = append(errs, configError(defaultTimeout.Key, "%d must be > 0", timeoutDuration))
+	}
+
+	fullRefreshInterval, errFullRefreshInterval := strconv.Atoi(fullRefreshIntervalEnv)
+	if errFullRefreshInterval != nil {
+		errs = append(errs, configError(defaultFullRefreshInterval.Key, "%s must be an integer", fullRefreshIntervalEnv))
+	} else if fullRefreshInterval < 1 {
+		errs = append(errs, configError(defaultFullRefreshInterval.Key, "%d must be >= 1", fullRefreshInterval))
+	}
+
 	// If a custom CA is provided and INSECURE_SKIP_VERIFY is set, that's kinda sus
 	if certificateAuthorityPath != nil && envSetToTrue(insecureSkipVerify) {
-		logger.Warn(fmt.Sprintf("You provided a custom CA and disabled certificate validation (check %s and %s)",
-			defaultCertificateAuthorityPath, defaultInsecureSkipVerify.Key))
+		logger.Warn(fmt.Sprintf("%sYou provided a custom CA and disabled certificate validation (check %s and %s)",
+			logPrefix, defaultCertificateAuthorityPath, defaultInsecureSkipVerify.Key))
 	}
 
 	// If a custom CA is provided or INSECURE_SKIP_VERIFY is set and the exporter URL is not HTTPS, that's kinda sus
 	if (certificateAuthorityPath != nil || envSetToTrue(insecureSkipVerify)) && !internal.IsValidHttpsURL(baseUrl) {
-		logger.Warn(fmt.Sprintf("You provided a custom CA or disabled certificate validation but the qBittorrent URL is not HTTPS. (check %s, %s and %s)",
-			defaultCertificateAuthorityPath, defaultInsecureSkipVerify.Key, defaultBaseUrl.Key))
+		logger.Warn(fmt.Sprintf("%sYou provided a custom CA or disabled certificate validation but the qBittorrent URL is not HTTPS. (check %s, %s and %s)",
+			logPrefix, defaultCertificateAuthorityPath, defaultInsecureSkipVerify.Key, defaultBaseUrl.Key))
 	}
 
 	// If a custom CA is provided, load the root CAs from the system and append the custom CA
 	var caCertPool *x509.CertPool
 
 	if certificateAuthorityPath != nil {
-		caCert, errCaCert := os.ReadFile(*certificateAuthorityPath)
-		if errCaCert != nil {
-			panic(fmt.Sprintf("Error reading certificate authority file: %s (check %s)",
-				errCaCert, defaultCertificateAuthorityPath))
-		}
-
 		var errCaCertPool error
 
-		caCertPool, errCaCertPool = x509.SystemCertPool()
+		caCertPool, errCaCertPool = loadCertificateAuthority(*certificateAuthorityPath)
 		if errCaCertPool != nil {
-			panic(fmt.Sprintf("Error getting system certificate pool: %s", errCaCertPool))
-		}
-
-		if !caCertPool.AppendCertsFromPEM(caCert) {
-			panic(fmt.Sprintf("Error adding custom certificate authority to pool: %s", errCaCert))
+			errs = append(errs, errCaCertPool)
 		}
 	}
 
@@ -275,24 +852,22 @@ func LoadEnv() {
 	case TLS13:
 		minTlsVersion = tls.VersionTLS13
 	default:
-		panic(fmt.Sprintf("Invalid minimum TLS version: %s (valid options are %s and %s) (check %s)",
-			minTlsVersionStr, TLS12, TLS13, defaultMinTlsVersion.Key))
+		errs = append(errs, configError(defaultMinTlsVersion.Key,
+			"invalid minimum TLS version: %s (valid options are %s and %s)", minTlsVersionStr, TLS12, TLS13))
+	}
+
+	err := errors.Join(errs...)
+	if err != nil {
+		return QBittorrentSettings{}, err //nolint:exhaustruct
 	}
 
 	qbittorrentBasicAuth := getBasicAuth(qbitBasicAuthUsername, qbitBasicAuthPassword, defaultBasicAuthUsername, defaultBasicAuthPassword)
-	exporterBasicAuth := getBasicAuth(basicAuthUsername, basicAuthPassword, defaultBasicAuthUsername, defaultBasicAuthPassword)
 
 	if qbittorrentBasicAuth != nil {
-		logger.Info("Enabling qBittorrent Basic Auth request header.")
+		logger.Info(logPrefix + "Enabling qBittorrent Basic Auth request header.")
 	}
 
-	if exporterBasicAuth != nil {
-		logger.Info("Using basic auth to protect the exporter instance")
-	} else {
-		logger.Trace("Not using basic auth to protect the exporter instance")
-	}
-
-	HttpClient = http.Client{ //nolint:exhaustruct
+	httpClient := &http.Client{ //nolint:exhaustruct
 		Transport: &http.Transport{ //nolint:exhaustruct
 			TLSClientConfig: &tls.Config{ //nolint:exhaustruct
 				RootCAs:            caCertPool,
@@ -302,9 +877,8 @@ func LoadEnv() {
 		},
 	}
 
-	internal.EnsureLeadingSlash(&exporterPath)
-
-	QBittorrent = QBittorrentSettings{
+	return QBittorrentSettings{
+		Name:       name,
 		BaseUrl:    baseUrl,
 		LegacyAuth: &legacyAuth,
 
@@ -313,28 +887,29 @@ func LoadEnv() {
 		APIKey:              apiKey,
 		FullRefreshInterval: fullRefreshInterval,
 		BasicAuth:           qbittorrentBasicAuth,
+		HttpClient:          httpClient,
+	}, nil
+}
+
+// loadCertificateAuthority returns the root CAs of the system with the custom
+// CA of path.
+func loadCertificateAuthority(path string) (*x509.CertPool, error) {
+	caCert, err := os.ReadFile(path)
+	if err != nil {
+		return nil, configError(defaultCertificateAuthorityPath, "error reading certificate authority file: %s", err)
 	}
 
-	Exporter = ExporterSettings{
-		Features: Features{
-			EnableIncreasedCardinality: envSetToTrue(enableIncreasedCardinality),
-			EnableHighCardinality:      envSetToTrue(enableHighCardinality),
-			EnableTracker:              envSetToTrue(enableTracker),
-			ShowPassword:               showPassword,
-		},
-		ExperimentalFeatures: ExperimentalFeatures{
-			EnableLabelWithHash:    envSetToTrue(labelWithHash),
-			EnableLabelWithTracker: envSetToTrue(labelWithTracker),
-			EnableLabelWithTags:    envSetToTrue(labelWithTag),
-		},
-		LogLevel:  loglevel,
-		Port:      exporterPort,
-		Host:      exporterHostEnv,
-		Path:      exporterPath,
-		BasicAuth: exporterBasicAuth,
+	caCertPool, err := x509.SystemCertPool()
+	if err != nil {
+		return nil, configError(defaultCertificateAuthorityPath, "error getting system certificate pool: %s", err)
 	}
 
-	logger.Info("Features enabled: " + getFeaturesEnabled())
+	if !caCertPool.AppendCertsFromPEM(caCert) {
+		return nil, configError(defaultCertificateAuthorityPath,
+			"error adding custom certificate authority to pool: no certificate found in %s", path)
+	}
+
+	return caCertPool, nil
 }
 
 func getBasicAuth(basicAuthUsername *string, basicAuthPassword *string, defaultBasicAuth string, defaultBasicPassword string) *BasicAuth {
@@ -371,7 +946,17 @@ func GetPasswordMasked(password string) string {
 	return strings.Repeat("*", len(password))
 }
 
+// Version returns the version of the exporter.
+func Version() string {
+	return version
+}
+
 func getFeaturesEnabled() string {
+	return fmt.Sprintf("[%s]", strings.Join(EnabledFeatures(), ", "))
+}
+
+// EnabledFeatures returns the labels of the enabled features.
+func EnabledFeatures() []string {
 	type feature struct {
 		enabled      bool
 		label        string
@@ -382,6 +967,16 @@ func getFeaturesEnabled() string {
 		{Exporter.Features.EnableHighCardinality, "High cardinality", false},
 		{Exporter.Features.EnableIncreasedCardinality, "Increased cardinality", false},
 		{Exporter.Features.EnableTracker, "Trackers", false},
+		{Exporter.Features.EnableTrackerTorrents, "Trackers of every torrent", false},
+		{Exporter.Features.EnableProbe, "Probe", false},
+		{Exporter.Features.EnableAggregates, "Aggregates", false},
+		{Exporter.Features.EnablePeers, "Peers", false},
+		{Exporter.Features.EnableFiles, "Files", false},
+		{Exporter.Features.EnableProperties, "Properties", false},
+		{Exporter.Features.EnableLog, "Log", false},
+		{Exporter.Features.EnableRSS, "RSS", false},
+		{Exporter.Features.EnableLegacyGauges, "Legacy gauges", false},
+		{Exporter.Features.EnableProcessMetrics, "Process metrics", false},
 		{Exporter.Features.ShowPassword, "Show password", false},
 		{Exporter.ExperimentalFeatures.EnableLabelWithTracker, "Label with tracker", true},
 		{Exporter.ExperimentalFeatures.EnableLabelWithHash, "Label with hash", true},
@@ -401,24 +996,26 @@ func getFeaturesEnabled() string {
 		}
 	}
 
-	return fmt.Sprintf("[%s]", strings.Join(features, ", "))
+	return features
 }
 
-func getPassword() (string, bool) {
-	passwordFile := getOptionalEnv(defaultPasswordFile)
+func getPassword(instance string) (string, bool, error) {
+	passwordFile := getInstanceOptionalEnv(instance, defaultPasswordFile)
 
 	// Prefer password from file over environment variable *if* password from
 	// file is set. This avoids getting and logging the default password.
 	if passwordFile != nil {
 		fileContent, err := os.ReadFile(*passwordFile)
 		if err != nil {
-			panic(err)
+			return "", false, configError(instanceEnvKey(instance, defaultPasswordFile), "error reading the password file: %s", err)
 		}
 
 		logger.Info("password read from: " + *passwordFile)
 
-		return strings.TrimSpace(string(fileContent)), false
+		return strings.TrimSpace(string(fileContent)), false, nil
 	} else {
-		return getEnv(defaultPassword)
+		password, usingDefaultValue := getInstanceEnv(instance, defaultPassword)
+
+		return password, usingDefaultValue, nil
 	}
 }
@@ -1,8 +1,12 @@
 package app
 
 import (
+	"errors"
 	"os"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestGetFeaturesEnabled(t *testing.T) {
@@ -20,6 +24,16 @@ func TestGetFeaturesEnabled(t *testing.T) {
 			features: Features{
 				EnableHighCardinality:      false,
 				EnableTracker:              false,
+				EnableTrackerTorrents:      false,
+				EnableProbe:                false,
+				EnableAggregates:           false,
+				EnablePeers:                false,
+				EnableFiles:                false,
+				EnableProperties:           false,
+				EnableLog:                  false,
+				EnableRSS:                  false,
+				EnableLegacyGauges:         false,
+				EnableProcessMetrics:       false,
 				EnableIncreasedCardinality: false,
 				ShowPassword:               false,
 			},
@@ -35,6 +49,16 @@ func TestGetFeaturesEnabled(t *testing.T) {
 			features: Features{
 				EnableHighCardinality:      true,
 				EnableTracker:              false,
+				EnableTrackerTorrents:      false,
+				EnableProbe:                false,
+				EnableAggregates:           false,
+				EnablePeers:                false,
+				EnableFiles:                false,
+				EnableProperties:           false,
+				EnableLog:                  false,
+				EnableRSS:                  false,
+				EnableLegacyGauges:         false,
+				EnableProcessMetrics:       false,
 				EnableIncreasedCardinality: false,
 				ShowPassword:               false,
 			},
@@ -50,6 +74,16 @@ func TestGetFeaturesEnabled(t *testing.T) {
 			features: Features{
 				EnableHighCardinality:      false,
 				EnableTracker:              true,
+				EnableTrackerTorrents:      false,
+				EnableProbe:                false,
+				EnableAggregates:           false,
+				EnablePeers:                false,
+				EnableFiles:                false,
+				EnableProperties:           false,
+				EnableLog:                  false,
+				EnableRSS:                  false,
+				EnableLegacyGauges:         false,
+				EnableProcessMetrics:       false,
 				EnableIncreasedCardinality: false,
 				ShowPassword:               false,
 			},
@@ -65,6 +99,16 @@ func TestGetFeaturesEnabled(t *testing.T) {
 			features: Features{
 				EnableHighCardinality:      true,
 				EnableTracker:              true,
+				EnableTrackerTorrents:      false,
+				EnableProbe:                false,
+				EnableAggregates:           false,
+				EnablePeers:                false,
+				EnableFiles:                false,
+				EnableProperties:           false,
+				EnableLog:                  false,
+				EnableRSS:                  false,
+				EnableLegacyGauges:         false,
+				EnableProcessMetrics:       false,
 				EnableIncreasedCardinality: false,
 				ShowPassword:               false,
 			},
@@ -80,6 +124,16 @@ func TestGetFeaturesEnabled(t *testing.T) {
 			features: Features{
 				EnableHighCardinality:      false,
 				EnableTracker:              false,
+				EnableTrackerTorrents:      false,
+				EnableProbe:                false,
+				EnableAggregates:           false,
+				EnablePeers:                false,
+				EnableFiles:                false,
+				EnableProperties:           false,
+				EnableLog:                  false,
+				EnableRSS:                  false,
+				EnableLegacyGauges:         false,
+				EnableProcessMetrics:       false,
 				EnableIncreasedCardinality: false,
 				ShowPassword:               false,
 			},
@@ -95,6 +149,16 @@ func TestGetFeaturesEnabled(t *testing.T) {
 			features: Features{
 				EnableHighCardinality:      true,
 				EnableTracker:              true,
+				EnableTrackerTorrents:      false,
+				EnableProbe:                false,
+				EnableAggregates:           false,
+				EnablePeers:                false,
+				EnableFiles:                false,
+				EnableProperties:           false,
+				EnableLog:                  false,
+				EnableRSS:                  false,
+				EnableLegacyGauges:         false,
+				EnableProcessMetrics:       false,
 				EnableIncreasedCardinality: false,
 				ShowPassword:               false,
 			},
@@ -172,9 +236,9 @@ func TestPassFileSet(t *testing.T) { //nolint:paralleltest
 	cleanEnvFile := setPassFile(t, expected)
 	defer cleanEnvFile()
 
-	got, usingDefaultValue := getPassword()
+	got, usingDefaultValue, err := getPassword("")
 
-	if got != expected || usingDefaultValue {
+	if err != nil || got != expected || usingDefaultValue {
 		t.Errorf("GetPassword() = %q; want %q", got, expected)
 	}
 }
@@ -190,9 +254,9 @@ func TestPassFileAndPassSet(t *testing.T) { //nolint:paralleltest
 	cleanEnv := setAndClearEnv(t, "QBITTORRENT_PASSWORD", "anotherpass")
 	defer cleanEnv()
 
-	got, usingDefaultValue := getPassword()
+	got, usingDefaultValue, err := getPassword("")
 
-	if got != expected || usingDefaultValue {
+	if err != nil || got != expected || usingDefaultValue {
 		t.Errorf("GetPassword() = %q; want %q", got, expected)
 	}
 }
@@ -298,3 +362,229 @@ func TestGetPasswordMasked(t *testing.T) {
 		})
 	}
 }
+
+func TestGetPollSettings(t *testing.T) {
+	t.Parallel()
+
+	ptr := func(s string) *string { return &s }
+
+	tests := []struct {
+		name             string
+		pollInterval     string
+		pollMaxAge       *string
+		expectedInterval time.Duration
+		expectedMaxAge   time.Duration
+	}{
+		{"Disabled", "0", ptr("60"), 0, 0},
+		{"Default max age", "15", nil, 15 * time.Second, 45 * time.Second},
+		{"Empty max age", "15", ptr(""), 15 * time.Second, 45 * time.Second},
+		{"Custom max age", "15", ptr("20"), 15 * time.Second, 20 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			interval, maxAge, err := getPollSettings(tt.pollInterval, tt.pollMaxAge)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if interval != tt.expectedInterval || maxAge != tt.expectedMaxAge {
+				t.Errorf("expected %s and %s, got %s and %s", tt.expectedInterval, tt.expectedMaxAge, interval, maxAge)
+			}
+		})
+	}
+}
+
+func TestGetTrackerURLLabel(t *testing.T) {
+	t.Parallel()
+
+	for _, value := range []string{TrackerURLRedacted, TrackerURLHost, TrackerURLRaw} {
+		if got, err := getTrackerURLLabel(value); err != nil || got != value {
+			t.Errorf("expected %s, got %s (%v)", value, got, err)
+		}
+	}
+
+	_, err := getTrackerURLLabel("passkey")
+	assertConfigError(t, err, defaultTrackerURLLabel.Key)
+}
+
+func TestGetTopTorrentsSettings(t *testing.T) {
+	t.Parallel()
+
+	settings, err := getTopTorrentsSettings("20", TopByRatio)
+	if err != nil || settings.Count != 20 || settings.By != TopByRatio {
+		t.Errorf("unexpected settings %+v", settings)
+	}
+
+	for _, invalid := range [][2]string{{"-1", TopByRatio}, {"ten", TopByRatio}, {"20", "seeders"}} {
+		_, err := getTopTorrentsSettings(invalid[0], invalid[1])
+		if err == nil {
+			t.Errorf("expected an error for %v", invalid)
+		}
+	}
+}
+
+func TestGetPollSettingsInvalid(t *testing.T) {
+	t.Parallel()
+
+	maxAge := "10"
+
+	_, _, err := getPollSettings("15", &maxAge)
+	assertConfigError(t, err, defaultPollMaxAge)
+}
+
+func TestGetPeersSettings(t *testing.T) {
+	t.Parallel()
+
+	torrents := " ubuntu.iso, ,abc123 "
+
+	got, err := getPeersSettings(&torrents, "10", "5")
+	if err != nil || !slices.Equal(got.Torrents, []string{"ubuntu.iso", "abc123"}) || got.MaxGroups != 10 || got.MaxTorrents != 5 {
+		t.Errorf("unexpected peers settings %+v", got)
+	}
+
+	got, err = getPeersSettings(nil, "50", "20")
+	if err != nil || got.Torrents != nil || got.MaxGroups != 50 || got.MaxTorrents != 20 {
+		t.Errorf("unexpected default peers settings %+v", got)
+	}
+
+	_, err = getPeersSettings(nil, "50", "0")
+	assertConfigError(t, err, defaultPeersMaxTorrents.Key)
+}
+
+func TestGetFilesSettingsInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := getFilesSettings(nil, "0", "20")
+	assertConfigError(t, err, defaultFilesMaxPerTorrent.Key)
+
+	_, err = getFilesSettings(nil, "100", "0")
+	assertConfigError(t, err, defaultFilesMaxTorrents.Key)
+}
+
+// assertConfigError checks that err is a ConfigError for the key.
+func assertConfigError(t *testing.T, err error, key string) {
+	t.Helper()
+
+	var configError *ConfigError
+	if !errors.As(err, &configError) {
+		t.Fatalf("expected a ConfigError, got %v", err)
+	}
+
+	if configError.Path != key {
+		t.Errorf("expected an error for %s, got %s", key, configError.Path)
+	}
+}
+
+func TestGetTorrentMatcher(t *testing.T) { //nolint:paralleltest
+	t.Setenv("TORRENTS_INCLUDE_CATEGORIES", "movies, tv")
+	t.Setenv("TORRENTS_INCLUDE_TRACKERS", "Tracker.Example")
+	t.Setenv("TORRENTS_INCLUDE_NAME", "^Linux")
+
+	matcher, err := getTorrentMatcher(defaultTorrentsInclude)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(matcher.Categories) != 2 || matcher.Categories[1] != "tv" {
+		t.Errorf("unexpected categories %v", matcher.Categories)
+	}
+
+	if len(matcher.TrackerHosts) != 1 || matcher.TrackerHosts[0] != "tracker.example" {
+		t.Errorf("expected lowercased tracker hosts, got %v", matcher.TrackerHosts)
+	}
+
+	if matcher.Name == nil || !matcher.Name.MatchString("Linux.iso") {
+		t.Errorf("unexpected name regex %v", matcher.Name)
+	}
+
+	exclude, err := getTorrentMatcher(defaultTorrentsExclude)
+	if err != nil || !exclude.IsEmpty() {
+		t.Error("expected an empty exclude matcher")
+	}
+}
+
+func TestProcessMetricsPath(t *testing.T) { //nolint:paralleltest
+	t.Setenv(defaultProcessMetricsPath, "process")
+
+	loaded, err := loadSettings("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if loaded.exporter.ProcessMetricsPath != "/process" {
+		t.Errorf("expected /process, got %s", loaded.exporter.ProcessMetricsPath)
+	}
+
+	t.Setenv(defaultProcessMetricsPath, defaultExporterPath)
+
+	_, err = loadSettings("")
+	assertConfigError(t, err, defaultProcessMetricsPath)
+}
+
+func TestProbeSettings(t *testing.T) { //nolint:paralleltest
+	t.Setenv(defaultEnableProbe.Key, "true")
+
+	_, err := loadSettings("")
+	assertConfigError(t, err, defaultEnableProbe.Key)
+
+	t.Setenv(defaultBasicAuthUsername, "user")
+	t.Setenv(defaultBasicAuthPassword, "password")
+
+	_, err = loadSettings("")
+	assertConfigError(t, err, defaultProbeTargets)
+
+	t.Setenv(defaultProbeTargets, "192.168.1.10:8080, https://seedbox.example/")
+
+	loaded, err := loadSettings("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"http://192.168.1.10:8080", "https://seedbox.example"}
+	if !slices.Equal(loaded.probeModules[""].ProbeTargets, expected) {
+		t.Errorf("expected %v, got %v", expected, loaded.probeModules[""].ProbeTargets)
+	}
+}
+
+func TestLoadSettingsReportsEveryError(t *testing.T) { //nolint:paralleltest
+	t.Setenv(defaultTimeout.Key, "soon")
+	t.Setenv(defaultPort.Key, "http")
+	t.Setenv(defaultPeersMaxGroups.Key, "0")
+	t.Setenv(defaultProcessMetricsPath, defaultExporterPath)
+
+	_, err := loadSettings("")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	for _, key := range []string{defaultTimeout.Key, defaultPort.Key, defaultPeersMaxGroups.Key, defaultProcessMetricsPath} {
+		if !strings.Contains(err.Error(), key+": ") {
+			t.Errorf("expected an error for %s in\n%s", key, err)
+		}
+	}
+}
+
+func TestExporterPortRange(t *testing.T) { //nolint:paralleltest
+	for _, port := range []string{"0", "65535"} {
+		t.Setenv(defaultPort.Key, port)
+
+		_, err := loadSettings("")
+		if err != nil {
+			t.Errorf("unexpected error for port %s: %v", port, err)
+		}
+	}
+
+	for _, port := range []string{"-1", "65536"} {
+		t.Setenv(defaultPort.Key, port)
+
+		_, err := loadSettings("")
+		assertConfigError(t, err, defaultPort.Key)
+
+		if !strings.Contains(err.Error(), "must be >= 0 and <= 65535") {
+			t.Errorf("unexpected error for port %s: %v", port, err)
+		}
+	}
+}
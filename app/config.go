@@ -0,0 +1,477 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"qbit-exp/internal"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// configValues holds the values of the config file, by environment variable
+// name. Environment variables override them.
+var configValues map[string]string
+
+type configKind int
+
+const (
+	configString configKind = iota
+	configBool
+	configInt
+	configList
+	configRegex
+	configURL
+	configSection
+	configInstances
+)
+
+// configField describes a field of the config file and the environment
+// variable it sets.
+type configField struct {
+	kind configKind
+	key  string
+	// values are the valid values of a string field, any value when empty.
+	values []string
+	// minimum and maximum bound an int field.
+	minimum int
+	maximum int
+	fields  configFields
+	// requireBaseURL makes base_url mandatory in each entry of an instances
+	// field.
+	requireBaseURL bool
+}
+
+type configFields map[string]*configField
+
+func stringField(key string, values ...string) *configField {
+	return &configField{kind: configString, key: key, values: values} //nolint:exhaustruct
+}
+
+func boolField(key string) *configField {
+	return &configField{kind: configBool, key: key} //nolint:exhaustruct
+}
+
+func intField(key string, minimum, maximum int) *configField {
+	return &configField{kind: configInt, key: key, minimum: minimum, maximum: maximum} //nolint:exhaustruct
+}
+
+func listField(key string) *configField {
+	return &configField{kind: configList, key: key} //nolint:exhaustruct
+}
+
+func section(fields configFields) *configField {
+	return &configField{kind: configSection, fields: fields} //nolint:exhaustruct
+}
+
+// instanceFields are the fields of the qbittorrent section, and of each
+// entry of the instances and probe_modules lists.
+func instanceFields() configFields {
+	return configFields{
+		"base_url":              {kind: configURL, key: defaultBaseUrl.Key}, //nolint:exhaustruct
+		"username":              stringField(defaultUsername.Key),
+		"password":              stringField(defaultPassword.Key),
+		"password_file":         stringField(defaultPasswordFile),
+		"api_key":               stringField(defaultAPIKey),
+		"cookie_name":           stringField(defaultCookieName.Key),
+		"timeout":               intField(defaultTimeout.Key, 0, 0),
+		"full_refresh_interval": intField(defaultFullRefreshInterval.Key, 1, 0),
+		"basic_auth": section(configFields{
+			"username": stringField(defaultQbitBasicAuthUsername),
+			"password": stringField(defaultQbitBasicAuthPassword),
+		}),
+		"tls": section(configFields{
+			"certificate_authority_path": stringField(defaultCertificateAuthorityPath),
+			"insecure_skip_verify":       boolField(defaultInsecureSkipVerify.Key),
+			"min_version":                stringField(defaultMinTlsVersion.Key, TLS12, TLS13),
+		}),
+	}
+}
+
+// probeModuleFields are the fields of the qbittorrent section, which holds
+// the default probe module, and of each entry of the probe_modules list.
+func probeModuleFields() configFields {
+	fields := instanceFields()
+	fields["probe_targets"] = listField(defaultProbeTargets)
+
+	return fields
+}
+
+func torrentMatcherFields(prefix string) configFields {
+	return configFields{
+		"categories": listField(prefix + "CATEGORIES"),
+		"tags":       listField(prefix + "TAGS"),
+		"states":     listField(prefix + "STATES"),
+		"trackers":   listField(prefix + "TRACKERS"),
+		"save_paths": listField(prefix + "SAVE_PATHS"),
+		"name":       {kind: configRegex, key: prefix + "NAME"}, //nolint:exhaustruct
+	}
+}
+
+// configSchema returns the fields of the config file.
+func configSchema() configFields {
+	return configFields{
+		"log_level": stringField(defaultLogLevel.Key),
+		"exporter": section(configFields{
+			"host": stringField(defaultHost.Key),
+			"port": intField(defaultPort.Key, 0, maxExporterPort),
+			"path": stringField(defaultExporterPathEnv.Key),
+			"url":  {kind: configURL, key: defaultExporterURL}, //nolint:exhaustruct
+			"basic_auth": section(configFields{
+				"username": stringField(defaultBasicAuthUsername),
+				"password": stringField(defaultBasicAuthPassword),
+			}),
+			"show_password":        boolField(defaultExporterShowPassword.Key),
+			"tracker_url_label":    stringField(defaultTrackerURLLabel.Key, TrackerURLRedacted, TrackerURLHost, TrackerURLRaw),
+			"relabel_config_file":  stringField(defaultRelabelConfigFile),
+			"process_metrics_path": stringField(defaultProcessMetricsPath),
+			"poll": section(configFields{
+				"interval": intField(defaultPollInterval.Key, 0, 0),
+				"max_age":  intField(defaultPollMaxAge, 0, 0),
+			}),
+		}),
+		"features": section(configFields{
+			"aggregates":            boolField(defaultEnableAggregates.Key),
+			"tracker":               boolField(defaultEnableTracker.Key),
+			"tracker_torrents":      boolField(defaultEnableTrackerTorrents.Key),
+			"peers":                 boolField(defaultEnablePeers.Key),
+			"files":                 boolField(defaultEnableFiles.Key),
+			"properties":            boolField(defaultEnableProperties.Key),
+			"log":                   boolField(defaultEnableLog.Key),
+			"rss":                   boolField(defaultEnableRSS.Key),
+			"probe":                 boolField(defaultEnableProbe.Key),
+			"process_metrics":       boolField(defaultEnableProcessMetrics.Key),
+			"high_cardinality":      boolField(defaultHighCardinality.Key),
+			"increased_cardinality": boolField(defaultIncreasedCardinality.Key),
+			"legacy_gauges":         boolField(defaultLegacyGauges.Key),
+			"label_with_hash":       boolField(defaultLabelWithHash.Key),
+			"label_with_tracker":    boolField(defaultLabelWithTracker.Key),
+			"label_with_tags":       boolField(defaultLabelWithTag.Key),
+		}),
+		"peers": section(configFields{
+			"torrents":     listField(defaultPeersTorrents),
+			"max_groups":   intField(defaultPeersMaxGroups.Key, 1, 0),
+			"max_torrents": intField(defaultPeersMaxTorrents.Key, 1, 0),
+		}),
+		"files": section(configFields{
+			"torrents":        listField(defaultFilesTorrents),
+			"max_per_torrent": intField(defaultFilesMaxPerTorrent.Key, 1, 0),
+			"max_torrents":    intField(defaultFilesMaxTorrents.Key, 1, 0),
+		}),
+		"properties": section(configFields{
+			"torrents":     listField(defaultPropertiesTorrents),
+			"max_torrents": intField(defaultPropertiesMaxTorrents.Key, 1, 0),
+		}),
+		"torrents": section(configFields{
+			"include": section(torrentMatcherFields(defaultTorrentsInclude)),
+			"exclude": section(torrentMatcherFields(defaultTorrentsExclude)),
+			"top": section(configFields{
+				"count": intField(defaultTorrentsTop.Key, 0, 0),
+				"by":    stringField(defaultTorrentsTopBy.Key, TopByUploadSpeed, TopByDownloadSpeed, TopByRatio, TopBySize, TopByAddedOn),
+			}),
+		}),
+		"qbittorrent":   section(probeModuleFields()),
+		"instances":     {kind: configInstances, key: defaultInstances, fields: instanceFields(), requireBaseURL: true}, //nolint:exhaustruct
+		"probe_modules": {kind: configInstances, key: defaultProbeModules, fields: probeModuleFields()},                 //nolint:exhaustruct
+	}
+}
+
+// ConfigError is an invalid setting, at the path of its field in the config
+// file or at the name of its environment variable.
+type ConfigError struct {
+	Path    string
+	Message string
+}
+
+func (e *ConfigError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+
+	return e.Path + ": " + e.Message
+}
+
+// configError returns a ConfigError for the environment variable key.
+func configError(key string, format string, args ...any) error {
+	return &ConfigError{Path: key, Message: fmt.Sprintf(format, args...)}
+}
+
+// loadConfigFile reads the config file at path, in TOML when its extension is
+// .toml and in YAML otherwise, and returns its values by environment variable
+// name. Every invalid field is reported in the returned error.
+func loadConfigFile(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, configError(path, "error reading the config file: %s", err)
+	}
+
+	return parseConfig(content, strings.EqualFold(filepath.Ext(path), ".toml"))
+}
+
+func parseConfig(content []byte, isTOML bool) (map[string]string, error) {
+	var document map[string]any
+
+	var err error
+	if isTOML {
+		err = toml.Unmarshal(content, &document)
+	} else {
+		err = yaml.Unmarshal(content, &document)
+	}
+
+	if err != nil {
+		return nil, &ConfigError{Path: "", Message: "error parsing the config file: " + err.Error()}
+	}
+
+	loader := configLoader{values: make(map[string]string), errors: nil}
+	loader.section("", "", document, configSchema())
+
+	if len(loader.errors) > 0 {
+		return nil, errors.Join(loader.errors...)
+	}
+
+	return loader.values, nil
+}
+
+type configLoader struct {
+	values map[string]string
+	errors []error
+}
+
+func (l *configLoader) fail(path, format string, args ...any) {
+	l.errors = append(l.errors, &ConfigError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// section loads the fields of a section. The keys are those of the named
+// instance, the unprefixed ones when instance is empty.
+func (l *configLoader) section(path, instance string, document map[string]any, fields configFields) {
+	keys := make([]string, 0, len(document))
+	for key := range document {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		fieldPath := key
+		if path != "" {
+			fieldPath = path + "." + key
+		}
+
+		field, exists := fields[key]
+		if !exists {
+			l.fail(fieldPath, "unknown field")
+
+			continue
+		}
+
+		l.field(fieldPath, instance, document[key], field)
+	}
+}
+
+func (l *configLoader) field(path, instance string, value any, field *configField) {
+	switch field.kind {
+	case configSection:
+		document, ok := value.(map[string]any)
+		if !ok {
+			l.fail(path, "must be a section")
+
+			return
+		}
+
+		l.section(path, instance, document, field.fields)
+	case configInstances:
+		l.instances(path, value, field)
+	default:
+		if value, ok := l.scalar(path, value, field); ok {
+			l.values[instanceEnvKey(instance, field.key)] = value
+		}
+	}
+}
+
+// scalar returns the value of a field as written in its environment variable.
+func (l *configLoader) scalar(path string, value any, field *configField) (string, bool) {
+	switch field.kind {
+	case configBool:
+		b, ok := value.(bool)
+		if !ok {
+			l.fail(path, "must be a boolean")
+
+			return "", false
+		}
+
+		return strconv.FormatBool(b), true
+	case configInt:
+		return l.integer(path, value, field)
+	case configList:
+		return l.list(path, value)
+	default:
+		s, ok := value.(string)
+		if !ok {
+			l.fail(path, "must be a string")
+
+			return "", false
+		}
+
+		return s, l.check(path, s, field)
+	}
+}
+
+func (l *configLoader) integer(path string, value any, field *configField) (string, bool) {
+	var number int
+
+	switch v := value.(type) {
+	case int:
+		number = v
+	case int64:
+		number = int(v)
+	case uint64:
+		number = int(v) //nolint:gosec
+	default:
+		l.fail(path, "must be an integer")
+
+		return "", false
+	}
+
+	if number < field.minimum {
+		l.fail(path, "must be >= %d", field.minimum)
+
+		return "", false
+	}
+
+	if field.maximum > 0 && number > field.maximum {
+		l.fail(path, "must be <= %d", field.maximum)
+
+		return "", false
+	}
+
+	return strconv.Itoa(number), true
+}
+
+// list returns a list, or a comma separated string, as a comma separated
+// string.
+func (l *configLoader) list(path string, value any) (string, bool) {
+	if s, ok := value.(string); ok {
+		return s, true
+	}
+
+	items, ok := value.([]any)
+	if !ok {
+		l.fail(path, "must be a list")
+
+		return "", false
+	}
+
+	values := make([]string, 0, len(items))
+	valid := true
+
+	for i, item := range items {
+		s, ok := item.(string)
+		if !ok || strings.Contains(s, ",") {
+			l.fail(fmt.Sprintf("%s[%d]", path, i), "must be a string without comma")
+
+			valid = false
+
+			continue
+		}
+
+		values = append(values, s)
+	}
+
+	return strings.Join(values, ","), valid
+}
+
+func (l *configLoader) check(path, value string, field *configField) bool {
+	if len(field.values) > 0 && !slices.Contains(field.values, value) {
+		l.fail(path, "invalid value %q (valid options are %s)", value, strings.Join(field.values, ", "))
+
+		return false
+	}
+
+	switch field.kind {
+	case configURL:
+		if !internal.IsValidURL(strings.TrimSuffix(value, "/")) {
+			l.fail(path, "%s is not a valid URL", value)
+
+			return false
+		}
+	case configRegex:
+		_, err := regexp.Compile(value)
+		if err != nil {
+			l.fail(path, "invalid regular expression: %s", err)
+
+			return false
+		}
+	}
+
+	return true
+}
+
+// instances loads a list of named instances, and sets field.key to their
+// names.
+func (l *configLoader) instances(path string, value any, field *configField) {
+	var entries []map[string]any
+
+	switch v := value.(type) {
+	case []map[string]any:
+		entries = v
+	case []any:
+		for i, item := range v {
+			entry, ok := item.(map[string]any)
+			if !ok {
+				l.fail(fmt.Sprintf("%s[%d]", path, i), "must be a section")
+
+				continue
+			}
+
+			entries = append(entries, entry)
+		}
+	default:
+		l.fail(path, "must be a list")
+
+		return
+	}
+
+	names := make([]string, 0, len(entries))
+	envNames := make(map[string]string, len(entries))
+
+	for i, entry := range entries {
+		entryPath := fmt.Sprintf("%s[%d]", path, i)
+
+		name, ok := entry["name"].(string)
+		if !ok || strings.TrimSpace(name) == "" || strings.Contains(name, ",") {
+			l.fail(entryPath+".name", "must be a non-empty string without comma")
+
+			continue
+		}
+
+		name = strings.TrimSpace(name)
+
+		if other, exists := envNames[instanceEnvName(name)]; exists {
+			l.fail(entryPath+".name", "%s uses the same environment variables prefix as %s", name, other)
+
+			continue
+		}
+
+		envNames[instanceEnvName(name)] = name
+		names = append(names, name)
+
+		if _, exists := entry["base_url"]; field.requireBaseURL && !exists {
+			l.fail(entryPath+".base_url", "is required")
+		}
+
+		settings := maps.Clone(entry)
+		delete(settings, "name")
+
+		l.section(entryPath, name, settings, field.fields)
+	}
+
+	l.values[field.key] = strings.Join(names, ",")
+}
@@ -0,0 +1,236 @@
+package app
+
+import (
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseConfigYAML(t *testing.T) {
+	t.Parallel()
+
+	content := `
+log_level: DEBUG
+exporter:
+  port: 9000
+  basic_auth:
+    username: prometheus
+  poll:
+    interval: 15
+features:
+  peers: true
+  legacy_gauges: false
+peers:
+  torrents: [Linux.iso, abcdef]
+torrents:
+  include:
+    categories: movies,tv
+    name: ^Linux
+  top:
+    count: 20
+    by: ratio
+qbittorrent:
+  api_key: "123"
+  tls:
+    min_version: TLS_1_2
+instances:
+  - name: seed-box
+    base_url: http://seedbox:8080
+    tls:
+      insecure_skip_verify: true
+  - name: home
+    base_url: http://home:8080
+`
+
+	values, err := parseConfig([]byte(content), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"LOG_LEVEL":                                 "DEBUG",
+		"EXPORTER_PORT":                             "9000",
+		"EXPORTER_BASIC_AUTH_USERNAME":              "prometheus",
+		"POLL_INTERVAL":                             "15",
+		"ENABLE_PEERS":                              "true",
+		"ENABLE_LEGACY_GAUGES":                      "false",
+		"PEERS_TORRENTS":                            "Linux.iso,abcdef",
+		"TORRENTS_INCLUDE_CATEGORIES":               "movies,tv",
+		"TORRENTS_INCLUDE_NAME":                     "^Linux",
+		"TORRENTS_TOP":                              "20",
+		"TORRENTS_TOP_BY":                           "ratio",
+		"QBITTORRENT_API_KEY":                       "123",
+		"MIN_TLS_VERSION":                           "TLS_1_2",
+		"QBITTORRENT_INSTANCES":                     "seed-box,home",
+		"QBITTORRENT_SEED_BOX_BASE_URL":             "http://seedbox:8080",
+		"QBITTORRENT_SEED_BOX_INSECURE_SKIP_VERIFY": "true",
+		"QBITTORRENT_HOME_BASE_URL":                 "http://home:8080",
+	}
+
+	if !maps.Equal(values, expected) {
+		t.Errorf("expected %v, got %v", expected, values)
+	}
+}
+
+func TestParseConfigTOML(t *testing.T) {
+	t.Parallel()
+
+	content := `
+[exporter]
+port = 9000
+
+[features]
+rss = true
+
+[[instances]]
+name = "seedbox"
+base_url = "http://seedbox:8080"
+timeout = 10
+`
+
+	values, err := parseConfig([]byte(content), true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"EXPORTER_PORT":                "9000",
+		"ENABLE_RSS":                   "true",
+		"QBITTORRENT_INSTANCES":        "seedbox",
+		"QBITTORRENT_SEEDBOX_BASE_URL": "http://seedbox:8080",
+		"QBITTORRENT_SEEDBOX_TIMEOUT":  "10",
+	}
+
+	if !maps.Equal(values, expected) {
+		t.Errorf("expected %v, got %v", expected, values)
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	t.Parallel()
+
+	content := `
+exporter:
+  port: 70000
+  url: not a url
+features:
+  peer: true
+  rss: "yes"
+torrents:
+  include:
+    name: "("
+  top:
+    by: seeders
+qbittorrent:
+  tls: TLS_1_2
+instances:
+  - name: seedbox
+  - base_url: http://other:8080
+`
+
+	_, err := parseConfig([]byte(content), false)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	for _, path := range []string{
+		"exporter.port",
+		"exporter.url",
+		"features.peer",
+		"features.rss",
+		"torrents.include.name",
+		"torrents.top.by",
+		"qbittorrent.tls",
+		"instances[0].base_url",
+		"instances[1].name",
+	} {
+		if !strings.Contains(err.Error(), path+": ") {
+			t.Errorf("expected an error for %s in\n%s", path, err)
+		}
+	}
+
+	var configError *ConfigError
+	if !errors.As(err, &configError) {
+		t.Errorf("expected a ConfigError, got %T", err)
+	}
+}
+
+func TestConfigSchemaCoversEnvs(t *testing.T) {
+	t.Parallel()
+
+	keys := make(map[string]struct{})
+
+	var collect func(fields configFields)
+
+	collect = func(fields configFields) {
+		for _, field := range fields {
+			if field.key != "" {
+				keys[field.key] = struct{}{}
+			}
+
+			collect(field.fields)
+		}
+	}
+
+	collect(configSchema())
+
+	envs := []Env{
+		defaultEnableTracker, defaultEnableTrackerTorrents, defaultLabelWithTracker, defaultLegacyGauges,
+		defaultTrackerURLLabel, defaultEnableAggregates, defaultEnablePeers, defaultPeersMaxGroups, defaultPeersMaxTorrents,
+		defaultEnableFiles, defaultFilesMaxPerTorrent, defaultFilesMaxTorrents, defaultEnableProperties, defaultPropertiesMaxTorrents,
+		defaultEnableLog, defaultEnableRSS, defaultEnableProbe, defaultEnableProcessMetrics, defaultPollInterval, defaultTorrentsTop,
+		defaultTorrentsTopBy, defaultExporterPathEnv, defaultExporterShowPassword, defaultHighCardinality,
+		defaultIncreasedCardinality, defaultLabelWithHash, defaultLabelWithTag, defaultLogLevel, defaultPort,
+		defaultHost, defaultBaseUrl, defaultInsecureSkipVerify, defaultMinTlsVersion, defaultPassword,
+		defaultUsername, defaultCookieName, defaultTimeout, defaultFullRefreshInterval,
+	}
+
+	names := []string{
+		defaultPeersTorrents, defaultFilesTorrents, defaultPropertiesTorrents, defaultProbeModules, defaultProbeTargets,
+		defaultPollMaxAge, defaultRelabelConfigFile, defaultExporterURL, defaultBasicAuthUsername,
+		defaultBasicAuthPassword, defaultCertificateAuthorityPath, defaultAPIKey, defaultInstances,
+		defaultPasswordFile, defaultQbitBasicAuthUsername, defaultQbitBasicAuthPassword, defaultProcessMetricsPath,
+		defaultTorrentsInclude + "NAME", defaultTorrentsExclude + "NAME",
+	}
+
+	for _, env := range envs {
+		names = append(names, env.Key)
+	}
+
+	for _, name := range names {
+		if _, exists := keys[name]; !exists {
+			t.Errorf("%s is not in the config file", name)
+		}
+	}
+}
+
+func TestGetEnvFromConfig(t *testing.T) { //nolint:paralleltest
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	err := os.WriteFile(path, []byte("exporter:\n  port: 9000\n  host: 127.0.0.1\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := loadConfigFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	configValues = values
+
+	defer func() { configValues = nil }()
+
+	t.Setenv(defaultHost.Key, "0.0.0.0")
+
+	if port, _ := getEnv(defaultPort); port != "9000" {
+		t.Errorf("expected the port of the config file, got %s", port)
+	}
+
+	if host, _ := getEnv(defaultHost); host != "0.0.0.0" {
+		t.Errorf("expected the environment variable to override the config file, got %s", host)
+	}
+}
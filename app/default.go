@@ -15,13 +15,31 @@ type Env struct {
 }
 
 const defaultExporterPort int = 8090
+const maxExporterPort int = 65535
 const DefaultTimeout int = 30
 const DefaultFullRefreshInterval int = 100
+const defaultPollMaxAgeIntervals int = 3
 const defaultExporterPath string = "/metrics"
 
 const TLS12 string = "TLS_1_2"
 const TLS13 string = "TLS_1_3"
 
+// Keys ranking the torrents in the top torrents mode.
+const (
+	TopByUploadSpeed   string = "upload_speed"
+	TopByDownloadSpeed string = "download_speed"
+	TopByRatio         string = "ratio"
+	TopBySize          string = "size"
+	TopByAddedOn       string = "added_on"
+)
+
+// How tracker URLs are written in label values.
+const (
+	TrackerURLRedacted string = "redacted"
+	TrackerURLHost     string = "host"
+	TrackerURLRaw      string = "raw"
+)
+
 // Exporter
 
 var defaultEnableTracker = Env{
@@ -30,12 +48,148 @@ var defaultEnableTracker = Env{
 	Help:         "",
 }
 
+var defaultEnableTrackerTorrents = Env{
+	Key:          "ENABLE_TRACKER_TORRENTS",
+	DefaultValue: "false",
+	Help:         "",
+}
+
 var defaultLabelWithTracker = Env{
 	Key:          "ENABLE_LABEL_WITH_TRACKER",
 	DefaultValue: "false",
 	Help:         "",
 }
 
+var defaultLegacyGauges = Env{
+	Key:          "ENABLE_LEGACY_GAUGES",
+	DefaultValue: "true",
+	Help:         "",
+}
+
+var defaultTrackerURLLabel = Env{
+	Key:          "TRACKER_URL_LABEL",
+	DefaultValue: TrackerURLRedacted,
+	Help:         "",
+}
+
+var defaultEnableAggregates = Env{
+	Key:          "ENABLE_AGGREGATES",
+	DefaultValue: "true",
+	Help:         "",
+}
+
+var defaultEnablePeers = Env{
+	Key:          "ENABLE_PEERS",
+	DefaultValue: "false",
+	Help:         "",
+}
+
+var defaultPeersTorrents = "PEERS_TORRENTS"
+
+var defaultPeersMaxGroups = Env{
+	Key:          "PEERS_MAX_GROUPS",
+	DefaultValue: "50",
+	Help:         "",
+}
+
+var defaultPeersMaxTorrents = Env{
+	Key:          "PEERS_MAX_TORRENTS",
+	DefaultValue: "20",
+	Help:         "",
+}
+
+var defaultEnableFiles = Env{
+	Key:          "ENABLE_FILES",
+	DefaultValue: "false",
+	Help:         "",
+}
+
+var defaultFilesTorrents = "FILES_TORRENTS"
+
+var defaultFilesMaxPerTorrent = Env{
+	Key:          "FILES_MAX_PER_TORRENT",
+	DefaultValue: "100",
+	Help:         "",
+}
+
+var defaultFilesMaxTorrents = Env{
+	Key:          "FILES_MAX_TORRENTS",
+	DefaultValue: "20",
+	Help:         "",
+}
+
+var defaultEnableProperties = Env{
+	Key:          "ENABLE_PROPERTIES",
+	DefaultValue: "false",
+	Help:         "",
+}
+
+var defaultPropertiesTorrents = "PROPERTIES_TORRENTS"
+
+var defaultPropertiesMaxTorrents = Env{
+	Key:          "PROPERTIES_MAX_TORRENTS",
+	DefaultValue: "50",
+	Help:         "",
+}
+
+var defaultEnableLog = Env{
+	Key:          "ENABLE_LOG",
+	DefaultValue: "false",
+	Help:         "",
+}
+
+var defaultEnableRSS = Env{
+	Key:          "ENABLE_RSS",
+	DefaultValue: "false",
+	Help:         "",
+}
+
+var defaultEnableProbe = Env{
+	Key:          "ENABLE_PROBE",
+	DefaultValue: "false",
+	Help:         "",
+}
+
+var defaultProbeModules = "PROBE_MODULES"
+
+var defaultProbeTargets = "QBITTORRENT_PROBE_TARGETS"
+
+var defaultEnableProcessMetrics = Env{
+	Key:          "ENABLE_PROCESS_METRICS",
+	DefaultValue: "false",
+	Help:         "",
+}
+
+var defaultProcessMetricsPath = "PROCESS_METRICS_PATH"
+
+var defaultPollInterval = Env{
+	Key:          "POLL_INTERVAL",
+	DefaultValue: "0",
+	Help:         "",
+}
+
+var defaultPollMaxAge = "POLL_MAX_AGE"
+
+var defaultRelabelConfigFile = "RELABEL_CONFIG_FILE"
+
+// Prefixes of the torrent filter variables, followed by CATEGORIES, TAGS,
+// STATES, TRACKERS, SAVE_PATHS or NAME.
+var defaultTorrentsInclude = "TORRENTS_INCLUDE_"
+
+var defaultTorrentsExclude = "TORRENTS_EXCLUDE_"
+
+var defaultTorrentsTop = Env{
+	Key:          "TORRENTS_TOP",
+	DefaultValue: "0",
+	Help:         "",
+}
+
+var defaultTorrentsTopBy = Env{
+	Key:          "TORRENTS_TOP_BY",
+	DefaultValue: TopByUploadSpeed,
+	Help:         "",
+}
+
 var defaultExporterURL = "EXPORTER_URL"
 
 var defaultExporterPathEnv = Env{
@@ -107,6 +261,8 @@ var defaultCertificateAuthorityPath = "CERTIFICATE_AUTHORITY_PATH"
 
 var defaultAPIKey = "QBITTORRENT_API_KEY" //nolint:gosec
 
+var defaultInstances = "QBITTORRENT_INSTANCES"
+
 var defaultInsecureSkipVerify = Env{
 	Key:          "INSECURE_SKIP_VERIFY",
 	DefaultValue: "false",
@@ -155,8 +311,20 @@ var defaultFullRefreshInterval = Env{
 	Help:         "",
 }
 
+// lookupEnv returns the value of the environment variable, or else the value
+// set for it in the config file.
+func lookupEnv(key string) (string, bool) {
+	if value, ok := os.LookupEnv(key); ok {
+		return value, true
+	}
+
+	value, ok := configValues[key]
+
+	return value, ok
+}
+
 func getEnv(env Env) (string, bool) {
-	if value, ok := os.LookupEnv(env.Key); ok && value != "" {
+	if value, ok := lookupEnv(env.Key); ok && value != "" {
 		return value, false
 	}
 
@@ -168,7 +336,7 @@ func getEnv(env Env) (string, bool) {
 }
 
 func getOptionalEnv(env string) *string {
-	if value, ok := os.LookupEnv(env); ok {
+	if value, ok := lookupEnv(env); ok {
 		return &value
 	}
 
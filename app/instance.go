@@ -0,0 +1,97 @@
+package app
+
+import (
+	"strings"
+)
+
+const instanceEnvPrefix string = "QBITTORRENT_"
+
+// getInstanceNames returns the instance names listed in QBITTORRENT_INSTANCES.
+func getInstanceNames() ([]string, error) {
+	return getNames(defaultInstances)
+}
+
+// getNames returns the comma separated names listed in the env variable. Since
+// names are used as a prefix of environment variables, they must be unique.
+func getNames(env string) ([]string, error) {
+	namesEnv := getOptionalEnv(env)
+	if namesEnv == nil {
+		return nil, nil
+	}
+
+	var names []string
+
+	seen := make(map[string]struct{})
+	envNames := make(map[string]string)
+
+	for name := range strings.SplitSeq(*namesEnv, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		if _, exists := seen[name]; exists {
+			return nil, configError(env, "%s is listed more than once", name)
+		}
+
+		envName := instanceEnvName(name)
+		if other, exists := envNames[envName]; exists {
+			return nil, configError(env, "%s and %s use the same environment variables prefix", other, name)
+		}
+
+		seen[name] = struct{}{}
+		envNames[envName] = name
+
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+// instanceEnvName converts an instance name to the form used in environment
+// variables names, e.g. "seed-box" becomes "SEED_BOX".
+func instanceEnvName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// instanceEnvKey returns the environment variable overriding key for the named
+// instance, e.g. QBITTORRENT_BASE_URL becomes QBITTORRENT_SEEDBOX_BASE_URL for
+// the instance "seedbox".
+func instanceEnvKey(instance string, key string) string {
+	if instance == "" {
+		return key
+	}
+
+	return instanceEnvPrefix + instanceEnvName(instance) + "_" + strings.TrimPrefix(key, instanceEnvPrefix)
+}
+
+// getInstanceEnv returns the value set for the instance, falling back to the
+// unprefixed environment variable and then to the default value.
+func getInstanceEnv(instance string, env Env) (string, bool) {
+	if instance != "" {
+		if value, ok := lookupEnv(instanceEnvKey(instance, env.Key)); ok && value != "" {
+			return value, false
+		}
+	}
+
+	return getEnv(env)
+}
+
+func getInstanceOptionalEnv(instance string, env string) *string {
+	if instance != "" {
+		if value := getOptionalEnv(instanceEnvKey(instance, env)); value != nil {
+			return value
+		}
+	}
+
+	return getOptionalEnv(env)
+}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestInstanceEnvKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		instance string
+		key      string
+		expected string
+	}{
+		{"No instance", "", "QBITTORRENT_BASE_URL", "QBITTORRENT_BASE_URL"},
+		{"Prefixed key", "seedbox", "QBITTORRENT_BASE_URL", "QBITTORRENT_SEEDBOX_BASE_URL"},
+		{"Unprefixed key", "seedbox", "MIN_TLS_VERSION", "QBITTORRENT_SEEDBOX_MIN_TLS_VERSION"},
+		{"Special characters", "seed-box.2", "QBITTORRENT_API_KEY", "QBITTORRENT_SEED_BOX_2_API_KEY"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := instanceEnvKey(tt.instance, tt.key)
+			if got != tt.expected {
+				t.Errorf("instanceEnvKey(%q, %q) = %q, want %q", tt.instance, tt.key, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetInstanceNames(t *testing.T) { //nolint:paralleltest
+	cleanEnv := setAndClearEnv(t, defaultInstances, " box1, ,box2 ")
+	defer cleanEnv()
+
+	got, err := getInstanceNames()
+
+	expected := []string{"box1", "box2"}
+	if err != nil || !slices.Equal(got, expected) {
+		t.Errorf("getInstanceNames() = %v, want %v", got, expected)
+	}
+}
+
+func TestGetInstanceNamesDuplicate(t *testing.T) { //nolint:paralleltest
+	cleanEnv := setAndClearEnv(t, defaultInstances, "seed-box,seed_box")
+	defer cleanEnv()
+
+	_, err := getInstanceNames()
+	assertConfigError(t, err, defaultInstances)
+}
+
+func TestGetInstanceEnvFallback(t *testing.T) { //nolint:paralleltest
+	cleanGlobal := setAndClearEnv(t, defaultTimeout.Key, "10")
+	defer cleanGlobal()
+
+	cleanInstance := setAndClearEnv(t, "QBITTORRENT_BOX1_TIMEOUT", "20")
+	defer cleanInstance()
+
+	if value, _ := getInstanceEnv("box1", defaultTimeout); value != "20" {
+		t.Errorf("expected the instance value 20, got %s", value)
+	}
+
+	if value, _ := getInstanceEnv("box2", defaultTimeout); value != "10" {
+		t.Errorf("expected the global value 10, got %s", value)
+	}
+
+	if value, _ := getInstanceEnv("", defaultTimeout); value != "10" {
+		t.Errorf("expected the global value 10, got %s", value)
+	}
+}
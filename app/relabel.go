@@ -0,0 +1,145 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+
+	"qbit-exp/logger"
+)
+
+// RelabelConfig rewrites the series of each instance before they are
+// exported. The regular expressions are fully anchored.
+type RelabelConfig struct {
+	// DropMetrics drops the metrics whose name matches one of the regular
+	// expressions.
+	DropMetrics []string `json:"drop_metrics"`
+	// KeepTorrents keeps only the per-torrent series of the torrents matching
+	// one of the selectors, when not empty.
+	KeepTorrents []TorrentSelector `json:"keep_torrents"`
+	// DropTorrents drops the per-torrent series of the torrents matching one
+	// of the selectors.
+	DropTorrents []TorrentSelector `json:"drop_torrents"`
+	// Replace rewrites label values, in order.
+	Replace []LabelReplacement `json:"replace"`
+	// DropLabels removes labels from every series.
+	DropLabels []string `json:"drop_labels"`
+	// RenameLabels renames labels, by current name.
+	RenameLabels map[string]string `json:"rename_labels"`
+}
+
+// TorrentSelector matches the torrents whose category and one of whose tags
+// match the regular expressions. An empty expression matches every torrent.
+type TorrentSelector struct {
+	Category string `json:"category"`
+	Tag      string `json:"tag"`
+}
+
+// LabelReplacement replaces the value of Label with Replacement when it
+// matches Regex. Replacement can refer to the capture groups with $1.
+type LabelReplacement struct {
+	Label       string `json:"label"`
+	Regex       string `json:"regex"`
+	Replacement string `json:"replacement"`
+}
+
+var labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// loadRelabelConfig reads and validates the relabel config file. It returns
+// nil without file.
+func loadRelabelConfig(path *string) (*RelabelConfig, error) {
+	if path == nil || *path == "" {
+		return nil, nil
+	}
+
+	content, err := os.ReadFile(*path)
+	if err != nil {
+		return nil, configError(defaultRelabelConfigFile, "can't read the relabel config: %s", err)
+	}
+
+	config, err := parseRelabelConfig(content)
+	if err != nil {
+		return nil, configError(defaultRelabelConfigFile, "invalid relabel config %s: %s", *path, err)
+	}
+
+	logger.Info("Relabel config read from: " + *path)
+
+	return config, nil
+}
+
+func parseRelabelConfig(content []byte) (*RelabelConfig, error) {
+	config := new(RelabelConfig)
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(config)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, expr := range config.DropMetrics {
+		err = checkRequiredRegex("drop_metrics", expr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, selectors := range [][]TorrentSelector{config.KeepTorrents, config.DropTorrents} {
+		for _, selector := range selectors {
+			err = checkRegex("category", selector.Category)
+			if err != nil {
+				return nil, err
+			}
+
+			err = checkRegex("tag", selector.Tag)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, replacement := range config.Replace {
+		if !labelNamePattern.MatchString(replacement.Label) {
+			return nil, fmt.Errorf("invalid label name %q in replace", replacement.Label)
+		}
+
+		err = checkRequiredRegex("replace", replacement.Regex)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, label := range config.DropLabels {
+		if !labelNamePattern.MatchString(label) {
+			return nil, fmt.Errorf("invalid label name %q in drop_labels", label)
+		}
+	}
+
+	for from, to := range config.RenameLabels {
+		if !labelNamePattern.MatchString(from) || !labelNamePattern.MatchString(to) {
+			return nil, fmt.Errorf("invalid label name in rename_labels: %q to %q", from, to)
+		}
+	}
+
+	return config, nil
+}
+
+func checkRequiredRegex(field, expr string) error {
+	if expr == "" {
+		return fmt.Errorf("empty regular expression in %s", field)
+	}
+
+	return checkRegex(field, expr)
+}
+
+func checkRegex(field, expr string) error {
+	_, err := regexp.Compile(expr)
+	if err != nil {
+		return fmt.Errorf("invalid regular expression in %s: %w", field, err)
+	}
+
+	return nil
+}
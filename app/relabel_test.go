@@ -0,0 +1,73 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseRelabelConfig(t *testing.T) {
+	t.Parallel()
+
+	config, err := parseRelabelConfig([]byte(`{
+		"drop_metrics": ["qbittorrent_torrent_file_.*"],
+		"keep_torrents": [{"category": "movies|tv"}],
+		"drop_torrents": [{"tag": "private"}],
+		"replace": [{"label": "name", "regex": "(.*)\\.mkv", "replacement": "$1"}],
+		"drop_labels": ["save_path"],
+		"rename_labels": {"name": "torrent"}
+	}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(config.DropMetrics) != 1 || config.KeepTorrents[0].Category != "movies|tv" || config.RenameLabels["name"] != "torrent" {
+		t.Errorf("unexpected config %+v", config)
+	}
+}
+
+func TestParseRelabelConfigInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"Unknown field", `{"drop_metric": ["a"]}`},
+		{"Invalid regex", `{"drop_metrics": ["("]}`},
+		{"Empty regex", `{"replace": [{"label": "name", "regex": "", "replacement": "x"}]}`},
+		{"Invalid selector", `{"keep_torrents": [{"tag": "["}]}`},
+		{"Invalid label", `{"drop_labels": ["save-path"]}`},
+		{"Invalid rename", `{"rename_labels": {"name": "1name"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := parseRelabelConfig([]byte(tt.content)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestLoadRelabelConfig(t *testing.T) {
+	t.Parallel()
+
+	if config, err := loadRelabelConfig(nil); config != nil || err != nil {
+		t.Error("expected no config without file")
+	}
+
+	path := filepath.Join(t.TempDir(), "relabel.json")
+
+	err := os.WriteFile(path, []byte(`{"drop_labels": ["hash"]}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := loadRelabelConfig(&path)
+	if err != nil || config == nil || config.DropLabels[0] != "hash" {
+		t.Errorf("unexpected config %+v", config)
+	}
+}
@@ -0,0 +1,180 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"qbit-exp/logger"
+)
+
+// settingsMu guards the settings against a reload: collections hold it for
+// reading, so that they see either the previous or the new settings.
+var settingsMu sync.RWMutex
+
+// collectionMu guards the settings against a reload during the background
+// collections. They don't hold settingsMu: a reload waiting for a collection
+// would block the scrapes, which only read the settings for a short time.
+// A reload locks it before settingsMu.
+var collectionMu sync.RWMutex
+
+// configFilePath is the config file given with --config, read again on reload.
+var configFilePath string
+
+// RLockSettings locks the settings for reading, until RUnlockSettings.
+func RLockSettings() {
+	settingsMu.RLock()
+}
+
+// RUnlockSettings undoes RLockSettings.
+func RUnlockSettings() {
+	settingsMu.RUnlock()
+}
+
+// RLockCollection locks the settings for reading during a background
+// collection, until RUnlockCollection. Unlike RLockSettings, a reload
+// waiting for the lock doesn't block the scrapes.
+func RLockCollection() {
+	collectionMu.RLock()
+}
+
+// RUnlockCollection undoes RLockCollection.
+func RUnlockCollection() {
+	collectionMu.RUnlock()
+}
+
+// settings are the values read by loadSettings.
+type settings struct {
+	qbittorrent QBittorrentSettings
+	exporter    ExporterSettings
+	httpClient  http.Client
+	// instances is nil without QBITTORRENT_INSTANCES, Instances then only
+	// contains &QBittorrent.
+	instances    []*QBittorrentSettings
+	probeModules map[string]*QBittorrentSettings
+}
+
+// apply replaces the current settings with s.
+func (s *settings) apply() {
+	QBittorrent = s.qbittorrent
+	Exporter = s.exporter
+	HttpClient = s.httpClient
+	Instances = s.instances
+	ProbeModules = s.probeModules
+
+	if Instances == nil {
+		QBittorrent.HttpClient = &HttpClient
+		Instances = []*QBittorrentSettings{&QBittorrent}
+	}
+
+	logger.SetLogLevel(Exporter.LogLevel)
+	logger.Info("Features enabled: " + getFeaturesEnabled())
+}
+
+// Reload reads the settings again from the environment variables and the
+// config file, the .env file is only read on startup. The settings are
+// replaced at once when they are valid, and apply is called before any
+// collection sees them. Otherwise the current ones are kept and the error is
+// returned.
+func Reload(apply func()) error {
+	collectionMu.Lock()
+	defer collectionMu.Unlock()
+
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+
+	var values map[string]string
+
+	if configFilePath != "" {
+		var err error
+
+		values, err = loadConfigFile(configFilePath)
+		if err != nil {
+			return err
+		}
+	}
+
+	configValues = values
+
+	loaded, err := loadSettings("Reloading the settings")
+	if err != nil {
+		return err
+	}
+
+	previous := Exporter
+
+	loaded.apply()
+	warnRestartRequired(&previous)
+	apply()
+
+	return nil
+}
+
+// warnRestartRequired warns about the changed settings only read on startup.
+func warnRestartRequired(previous *ExporterSettings) {
+	changes := []struct {
+		changed bool
+		key     string
+	}{
+		{previous.Port != Exporter.Port, defaultPort.Key},
+		{previous.Host != Exporter.Host, defaultHost.Key},
+		{previous.Path != Exporter.Path, defaultExporterPathEnv.Key},
+		{previous.PollInterval != Exporter.PollInterval, defaultPollInterval.Key},
+		{previous.PollMaxAge != Exporter.PollMaxAge, defaultPollMaxAge},
+		{previous.Features.EnableProbe != Exporter.Features.EnableProbe, defaultEnableProbe.Key},
+		{previous.ProcessMetricsPath != Exporter.ProcessMetricsPath, defaultProcessMetricsPath},
+	}
+
+	for _, change := range changes {
+		if change.changed {
+			logger.Warn(fmt.Sprintf("%s only changes on restart", change.key))
+		}
+	}
+}
+
+// WatchConfigFile calls reload when the modification time or the size of the
+// config file changes, checked every interval, until ctx is done. It returns
+// at once without config file.
+func WatchConfigFile(ctx context.Context, interval time.Duration, reload func()) {
+	path := configFilePath
+	if path == "" {
+		return
+	}
+
+	previous, err := os.Stat(path)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Can't watch the config file: %s", err))
+
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			logger.Warn(fmt.Sprintf("Can't read the config file: %s", err))
+
+			continue
+		}
+
+		if info.ModTime().Equal(previous.ModTime()) && info.Size() == previous.Size() {
+			continue
+		}
+
+		previous = info
+
+		logger.Info("The config file changed, reloading the settings")
+		reload()
+	}
+}
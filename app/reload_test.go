@@ -0,0 +1,162 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"qbit-exp/logger"
+)
+
+// saveSettings returns the current settings, restored with apply.
+func saveSettings() settings {
+	return settings{
+		qbittorrent:  QBittorrent,
+		exporter:     Exporter,
+		httpClient:   HttpClient,
+		instances:    Instances,
+		probeModules: ProbeModules,
+	}
+}
+
+func TestReload(t *testing.T) { //nolint:paralleltest
+	log := logger.Log
+	previous := saveSettings()
+
+	defer func() {
+		previous.apply()
+
+		configFilePath = ""
+		configValues = nil
+		logger.Log = log
+	}()
+
+	configFilePath = filepath.Join(t.TempDir(), "config.yaml")
+
+	writeConfig := func(content string) {
+		err := os.WriteFile(configFilePath, []byte(content), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeConfig("features:\n  peers: true\n")
+
+	applied := 0
+
+	err := Reload(func() { applied++ })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !Exporter.Features.EnablePeers || applied != 1 {
+		t.Errorf("expected the new settings to be applied, got %+v", Exporter.Features)
+	}
+
+	writeConfig("features:\n  peers: maybe\n")
+
+	err = Reload(func() { applied++ })
+	if err == nil {
+		t.Fatal("expected an error for an invalid config file")
+	}
+
+	writeConfig("exporter:\n  url: http://exporter:8090\npeers:\n  max_groups: 1\n")
+	t.Setenv(defaultPeersMaxGroups.Key, "0")
+
+	err = Reload(func() { applied++ })
+	if err == nil {
+		t.Fatal("expected an error for invalid settings")
+	}
+
+	if !Exporter.Features.EnablePeers || applied != 1 || Exporter.Peers.MaxGroups == 0 {
+		t.Error("expected the current settings to be kept")
+	}
+
+	if len(Instances) != 1 || Instances[0] != &QBittorrent || QBittorrent.HttpClient != &HttpClient {
+		t.Error("expected the current instance to be kept")
+	}
+}
+
+func TestReloadDuringCollection(t *testing.T) { //nolint:paralleltest
+	log := logger.Log
+	previous := saveSettings()
+
+	defer func() {
+		previous.apply()
+
+		logger.Log = log
+	}()
+
+	RLockCollection()
+
+	reloaded := make(chan error, 1)
+
+	go func() { reloaded <- Reload(func() {}) }()
+
+	// Give the reload the time to wait for the collection
+	time.Sleep(50 * time.Millisecond)
+
+	read := make(chan struct{})
+
+	go func() {
+		RLockSettings()
+		RUnlockSettings()
+		close(read)
+	}()
+
+	select {
+	case <-read:
+	case <-time.After(time.Second):
+		t.Fatal("expected the settings to be readable while the reload waits for the collection")
+	}
+
+	select {
+	case <-reloaded:
+		t.Fatal("expected the reload to wait for the collection")
+	default:
+	}
+
+	RUnlockCollection()
+
+	select {
+	case err := <-reloaded:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the reload to run after the collection")
+	}
+}
+
+func TestWatchConfigFile(t *testing.T) { //nolint:paralleltest
+	configFilePath = filepath.Join(t.TempDir(), "config.yaml")
+
+	defer func() { configFilePath = "" }()
+
+	err := os.WriteFile(configFilePath, []byte("features:\n  peers: true\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	reloads := make(chan struct{}, 1)
+
+	go WatchConfigFile(ctx, 10*time.Millisecond, func() { reloads <- struct{}{} })
+
+	time.Sleep(30 * time.Millisecond)
+
+	err = os.WriteFile(configFilePath, []byte("features:\n  peers: false\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-reloads:
+	case <-time.After(time.Second):
+		t.Error("expected a reload after the config file changed")
+	}
+}
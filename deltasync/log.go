@@ -0,0 +1,171 @@
+package deltasync
+
+import (
+	"maps"
+	"slices"
+	"sync"
+
+	API "qbit-exp/api"
+)
+
+// noLogEntry is the last_known_id returning the whole log.
+const noLogEntry int64 = -1
+
+// LogState holds the last log entries seen and the number of entries by
+// type between scrapes, so that each scrape only fetches the new entries.
+type LogState struct {
+	mu            sync.RWMutex
+	main          logCursor
+	peers         logCursor
+	entriesByType map[int64]uint64
+	bannedPeers   uint64
+}
+
+// logCursor is the last entry seen of a log.
+type logCursor struct {
+	id        int64
+	timestamp int64
+	// check requests the last entry seen again, to find out whether
+	// qBittorrent restarted and started the log again.
+	check bool
+}
+
+// NewLogState creates a new empty log state.
+func NewLogState() *LogState {
+	return &LogState{
+		mu:            sync.RWMutex{},
+		main:          logCursor{id: noLogEntry, timestamp: 0, check: false},
+		peers:         logCursor{id: noLogEntry, timestamp: 0, check: false},
+		entriesByType: make(map[int64]uint64),
+		bannedPeers:   0,
+	}
+}
+
+// CheckRestart requests the last entries seen again on the next scrape. When
+// they changed, qBittorrent restarted and its logs are read again from the
+// start. Otherwise only the new entries are counted, as usual.
+func (s *LogState) CheckRestart() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.main.check = true
+	s.peers.check = true
+}
+
+// GetLastMainID returns the last_known_id for log/main.
+func (s *LogState) GetLastMainID() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.main.lastKnownID()
+}
+
+// GetLastPeersID returns the last_known_id for log/peers.
+func (s *LogState) GetLastPeersID() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.peers.lastKnownID()
+}
+
+// GetEntriesByType returns the number of main log entries seen by type.
+func (s *LogState) GetEntriesByType() map[int64]uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return maps.Clone(s.entriesByType)
+}
+
+// GetBannedPeers returns the number of blocked peers seen in the peer log.
+func (s *LogState) GetBannedPeers() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.bannedPeers
+}
+
+// ApplyMain counts the new main log entries and returns them. It returns true
+// without counting anything when the log restarted, GetLastMainID then
+// requests the whole log.
+func (s *LogState) ApplyMain(entries []API.LogEntry) ([]API.LogEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	added, restarted := advance(&s.main, entries, func(entry API.LogEntry) (int64, int64) {
+		return entry.ID, entry.Timestamp
+	})
+
+	for _, entry := range added {
+		s.entriesByType[entry.Type]++
+	}
+
+	return added, restarted
+}
+
+// ApplyPeers counts the new blocked peers of the peer log. It returns true
+// without counting anything when the log restarted, GetLastPeersID then
+// requests the whole log.
+func (s *LogState) ApplyPeers(entries []API.PeerLogEntry) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	added, restarted := advance(&s.peers, entries, func(entry API.PeerLogEntry) (int64, int64) {
+		return entry.ID, entry.Timestamp
+	})
+
+	for _, entry := range added {
+		if entry.Blocked {
+			s.bannedPeers++
+		}
+	}
+
+	return restarted
+}
+
+// lastKnownID returns the last_known_id of the log, before the last entry
+// seen when checking it.
+func (c *logCursor) lastKnownID() int64 {
+	if c.check && c.id != noLogEntry {
+		return c.id - 1
+	}
+
+	return c.id
+}
+
+// advance returns the entries after the cursor, by ID, and moves the cursor to
+// the last of them. When checking, the last entry seen must come again with
+// the same timestamp: otherwise the log restarted, the cursor is reset and
+// advance returns true. The IDs of a restarted log start again from 0, so the
+// new entries can have IDs past the last one seen.
+func advance[E any](c *logCursor, entries []E, key func(E) (int64, int64)) ([]E, bool) {
+	if c.check {
+		c.check = false
+
+		seen := func(entry E) bool {
+			id, timestamp := key(entry)
+
+			return id == c.id && timestamp == c.timestamp
+		}
+		if c.id != noLogEntry && !slices.ContainsFunc(entries, seen) {
+			c.id = noLogEntry
+			c.timestamp = 0
+
+			return nil, true
+		}
+	}
+
+	var added []E
+
+	for _, entry := range entries {
+		id, timestamp := key(entry)
+		if id <= c.id {
+			continue
+		}
+
+		added = append(added, entry)
+		c.id = id
+		c.timestamp = timestamp
+	}
+
+	return added, false
+}
@@ -0,0 +1,123 @@
+package deltasync
+
+import (
+	"testing"
+
+	API "qbit-exp/api"
+)
+
+func TestLogState_ApplyMain(t *testing.T) {
+	t.Parallel()
+
+	state := NewLogState()
+
+	if state.GetLastMainID() != -1 || state.GetLastPeersID() != -1 {
+		t.Fatalf("expected the whole logs to be requested first")
+	}
+
+	state.ApplyMain([]API.LogEntry{
+		{ID: 0, Type: API.LogTypeNormal},   //nolint:exhaustruct
+		{ID: 1, Type: API.LogTypeCritical}, //nolint:exhaustruct
+	})
+	state.ApplyMain([]API.LogEntry{
+		{ID: 1, Type: API.LogTypeCritical}, //nolint:exhaustruct
+		{ID: 2, Type: API.LogTypeCritical}, //nolint:exhaustruct
+	})
+
+	entries := state.GetEntriesByType()
+	if entries[API.LogTypeNormal] != 1 || entries[API.LogTypeCritical] != 2 {
+		t.Errorf("expected each entry to be counted once, got %v", entries)
+	}
+
+	if state.GetLastMainID() != 2 {
+		t.Errorf("expected last main id 2, got %d", state.GetLastMainID())
+	}
+}
+
+func TestLogState_ApplyPeers(t *testing.T) {
+	t.Parallel()
+
+	state := NewLogState()
+
+	state.ApplyPeers([]API.PeerLogEntry{
+		{ID: 0, Blocked: true},  //nolint:exhaustruct
+		{ID: 1, Blocked: false}, //nolint:exhaustruct
+		{ID: 2, Blocked: true},  //nolint:exhaustruct
+	})
+
+	if state.GetBannedPeers() != 2 || state.GetLastPeersID() != 2 {
+		t.Errorf("expected 2 banned peers and last id 2, got %d and %d", state.GetBannedPeers(), state.GetLastPeersID())
+	}
+}
+
+func TestLogState_Restart(t *testing.T) {
+	t.Parallel()
+
+	state := NewLogState()
+
+	state.ApplyMain([]API.LogEntry{
+		{ID: 0, Timestamp: 100, Type: API.LogTypeNormal},   //nolint:exhaustruct
+		{ID: 1, Timestamp: 101, Type: API.LogTypeNormal},   //nolint:exhaustruct
+		{ID: 2, Timestamp: 102, Type: API.LogTypeCritical}, //nolint:exhaustruct
+	})
+	state.ApplyPeers([]API.PeerLogEntry{
+		{ID: 0, Timestamp: 100, Blocked: true}, //nolint:exhaustruct
+		{ID: 1, Timestamp: 101, Blocked: true}, //nolint:exhaustruct
+	})
+
+	// A full sync requests the last entries seen again, not the whole logs
+	state.CheckRestart()
+
+	if state.GetLastMainID() != 1 || state.GetLastPeersID() != 0 {
+		t.Fatalf("expected the last entries seen to be requested, got %d and %d", state.GetLastMainID(), state.GetLastPeersID())
+	}
+
+	added, restarted := state.ApplyMain([]API.LogEntry{
+		{ID: 2, Timestamp: 102, Type: API.LogTypeCritical}, //nolint:exhaustruct
+		{ID: 3, Timestamp: 103, Type: API.LogTypeCritical}, //nolint:exhaustruct
+	})
+	if restarted || len(added) != 1 || added[0].ID != 3 {
+		t.Errorf("expected only the new entry, got %v and restarted %t", added, restarted)
+	}
+
+	entries := state.GetEntriesByType()
+	if entries[API.LogTypeNormal] != 2 || entries[API.LogTypeCritical] != 2 {
+		t.Errorf("expected the entries seen to be skipped, got %v", entries)
+	}
+
+	if state.GetLastMainID() != 3 {
+		t.Errorf("expected last main id 3 once checked, got %d", state.GetLastMainID())
+	}
+
+	// After a restart of qBittorrent, the IDs start again from 0 and can
+	// already be past the last ID seen
+	state.CheckRestart()
+
+	_, restarted = state.ApplyMain([]API.LogEntry{
+		{ID: 3, Timestamp: 200, Type: API.LogTypeNormal},  //nolint:exhaustruct
+		{ID: 4, Timestamp: 201, Type: API.LogTypeWarning}, //nolint:exhaustruct
+	})
+	if !restarted || state.GetLastMainID() != -1 {
+		t.Fatalf("expected the restart to be detected and the whole log requested, got last id %d", state.GetLastMainID())
+	}
+
+	_, restarted = state.ApplyMain([]API.LogEntry{
+		{ID: 0, Timestamp: 197, Type: API.LogTypeNormal},   //nolint:exhaustruct
+		{ID: 1, Timestamp: 198, Type: API.LogTypeCritical}, //nolint:exhaustruct
+		{ID: 2, Timestamp: 199, Type: API.LogTypeNormal},   //nolint:exhaustruct
+		{ID: 3, Timestamp: 200, Type: API.LogTypeNormal},   //nolint:exhaustruct
+		{ID: 4, Timestamp: 201, Type: API.LogTypeWarning},  //nolint:exhaustruct
+	})
+
+	entries = state.GetEntriesByType()
+	if restarted || entries[API.LogTypeNormal] != 5 || entries[API.LogTypeCritical] != 3 || entries[API.LogTypeWarning] != 1 {
+		t.Errorf("expected every entry after the restart to be counted, got %v", entries)
+	}
+
+	// Without the last entry seen, an empty log means it was cleared too
+	state.CheckRestart()
+
+	if !state.ApplyPeers(nil) || state.GetLastPeersID() != -1 {
+		t.Errorf("expected the peer log to be read again, got last id %d", state.GetLastPeersID())
+	}
+}
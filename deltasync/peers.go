@@ -0,0 +1,84 @@
+package deltasync
+
+import (
+	"encoding/json"
+	"sync"
+
+	API "qbit-exp/api"
+)
+
+// PeersState holds the synchronized peers of a torrent between scrapes.
+// sync/torrentPeers has its own rid, so each torrent has its own state.
+type PeersState struct {
+	mu    sync.RWMutex
+	rid   int64
+	peers map[string]API.Peer
+}
+
+// NewPeersState creates a new empty peers state.
+func NewPeersState() *PeersState {
+	return &PeersState{
+		mu:    sync.RWMutex{},
+		rid:   0,
+		peers: make(map[string]API.Peer),
+	}
+}
+
+// GetRID returns the current response ID for delta requests.
+func (s *PeersState) GetRID() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.rid
+}
+
+// GetPeers returns a slice of all peers.
+func (s *PeersState) GetPeers() []API.Peer {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]API.Peer, 0, len(s.peers))
+	for _, peer := range s.peers {
+		result = append(result, peer)
+	}
+
+	return result
+}
+
+// Apply updates the state with delta data from sync/torrentPeers response.
+// If fullUpdate is true or this is the first update (rid=0), peers are replaced.
+// Otherwise, changes are merged into existing peers.
+func (s *PeersState) Apply(delta *API.DeltaTorrentPeers) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if delta.FullUpdate || s.rid == 0 {
+		s.peers = make(map[string]API.Peer, len(delta.Peers))
+	}
+
+	for key, raw := range delta.Peers {
+		existing := s.peers[key] // zero value if new peer
+
+		err := json.Unmarshal(raw, &existing)
+		if err != nil {
+			continue
+		}
+
+		s.peers[key] = existing
+	}
+
+	for _, key := range delta.PeersRemoved {
+		delete(s.peers, key)
+	}
+
+	s.rid = delta.Rid
+}
+
+// Reset clears all peers and resets rid to 0, forcing a full sync on next request.
+func (s *PeersState) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rid = 0
+	s.peers = make(map[string]API.Peer)
+}
@@ -0,0 +1,72 @@
+package deltasync
+
+import (
+	"encoding/json"
+	"testing"
+
+	API "qbit-exp/api"
+)
+
+func TestPeersState_Apply(t *testing.T) {
+	t.Parallel()
+
+	state := NewPeersState()
+
+	state.Apply(&API.DeltaTorrentPeers{ //nolint:exhaustruct
+		Rid:        1,
+		FullUpdate: true,
+		Peers: map[string]json.RawMessage{
+			"1.1.1.1:6881": raw(map[string]any{"client": "qBittorrent/5.0.0", "country_code": "fr", "up_speed": 100}),
+			"2.2.2.2:6881": raw(map[string]any{"client": "Transmission 4.0", "country_code": "de", "up_speed": 200}),
+		},
+	})
+
+	state.Apply(&API.DeltaTorrentPeers{ //nolint:exhaustruct
+		Rid: 2,
+		Peers: map[string]json.RawMessage{
+			"1.1.1.1:6881": raw(map[string]any{"up_speed": 300}),
+		},
+		PeersRemoved: []string{"2.2.2.2:6881"},
+	})
+
+	if state.GetRID() != 2 {
+		t.Errorf("expected rid 2, got %d", state.GetRID())
+	}
+
+	peers := state.GetPeers()
+	if len(peers) != 1 {
+		t.Fatalf("expected 1 peer, got %d", len(peers))
+	}
+
+	if peers[0].UpSpeed != 300 || peers[0].Client != "qBittorrent/5.0.0" || peers[0].CountryCode != "fr" {
+		t.Errorf("expected the delta to be merged into the peer, got %+v", peers[0])
+	}
+}
+
+func TestPeersState_FullUpdateReplacesPeers(t *testing.T) {
+	t.Parallel()
+
+	state := NewPeersState()
+
+	state.Apply(&API.DeltaTorrentPeers{ //nolint:exhaustruct
+		Rid:   1,
+		Peers: map[string]json.RawMessage{"1.1.1.1:6881": raw(map[string]any{"client": "a"})},
+	})
+
+	state.Apply(&API.DeltaTorrentPeers{ //nolint:exhaustruct
+		Rid:        2,
+		FullUpdate: true,
+		Peers:      map[string]json.RawMessage{"2.2.2.2:6881": raw(map[string]any{"client": "b"})},
+	})
+
+	peers := state.GetPeers()
+	if len(peers) != 1 || peers[0].Client != "b" {
+		t.Errorf("expected the full update to replace the peers, got %+v", peers)
+	}
+
+	state.Reset()
+
+	if state.GetRID() != 0 || len(state.GetPeers()) != 0 {
+		t.Errorf("expected an empty state after reset")
+	}
+}
@@ -16,6 +16,7 @@ type State struct {
 	torrents    map[string]API.Info
 	categories  map[string]API.Category
 	tags        []string
+	trackers    map[string][]string
 	serverState API.ServerState
 }
 
@@ -27,6 +28,7 @@ func NewState() *State {
 		torrents:    make(map[string]API.Info),
 		categories:  make(map[string]API.Category),
 		tags:        make([]string, 0),
+		trackers:    make(map[string][]string),
 		serverState: API.ServerState{}, //nolint:exhaustruct
 	}
 }
@@ -52,7 +54,8 @@ func (s *State) GetTorrents() API.SliceInfo {
 	return result
 }
 
-// GetMainData returns the current MainData (categories, tags, server state).
+// GetMainData returns the current MainData (categories, tags, trackers,
+// server state).
 func (s *State) GetMainData() API.MainData {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -63,10 +66,14 @@ func (s *State) GetMainData() API.MainData {
 	tags := make([]string, len(s.tags))
 	copy(tags, s.tags)
 
+	trackers := make(map[string][]string, len(s.trackers))
+	maps.Copy(trackers, s.trackers)
+
 	return API.MainData{
 		CategoryMap: categories,
 		ServerState: s.serverState,
 		Tags:        tags,
+		Trackers:    trackers,
 	}
 }
 
@@ -105,6 +112,7 @@ func (s *State) Reset() {
 	s.torrents = make(map[string]API.Info)
 	s.categories = make(map[string]API.Category)
 	s.tags = []string{}
+	s.trackers = make(map[string][]string)
 	s.serverState = API.ServerState{} //nolint:exhaustruct
 }
 
@@ -132,6 +140,10 @@ func (s *State) applyFullUpdate(delta *API.DeltaMainData) {
 	s.tags = make([]string, len(delta.Tags))
 	copy(s.tags, delta.Tags)
 
+	// Clear and rebuild trackers
+	s.trackers = make(map[string][]string, len(delta.Trackers))
+	maps.Copy(s.trackers, delta.Trackers)
+
 	// Replace server state (full update includes all fields)
 	s.serverState = API.ServerState{} //nolint:exhaustruct
 	if len(delta.ServerState) > 0 {
@@ -205,6 +217,14 @@ func (s *State) applyDeltaUpdate(delta *API.DeltaMainData) {
 		s.tags = filtered
 	}
 
+	// Apply tracker updates (the torrents of a tracker are sent in full)
+	maps.Copy(s.trackers, delta.Trackers)
+
+	// Remove deleted trackers
+	for _, url := range delta.TrackersRemoved {
+		delete(s.trackers, url)
+	}
+
 	// Merge server state (only update fields present in delta)
 	if len(delta.ServerState) > 0 {
 		_ = json.Unmarshal(delta.ServerState, &s.serverState)
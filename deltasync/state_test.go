@@ -406,6 +406,42 @@ func TestState_TagUpdates(t *testing.T) {
 	}
 }
 
+func TestState_TrackerUpdates(t *testing.T) {
+	t.Parallel()
+
+	state := NewState()
+
+	initialDelta := &API.DeltaMainData{ //nolint:exhaustruct
+		Rid:        100,
+		FullUpdate: true,
+		Torrents:   map[string]json.RawMessage{},
+		Trackers: map[string][]string{
+			"udp://a.example:6969/announce": {"hash1"},
+			"https://b.example/announce":    {"hash1", "hash2"},
+		},
+	}
+	state.Apply(initialDelta)
+
+	// Tracker of hash2 updated, tracker a removed
+	deltaDelta := &API.DeltaMainData{ //nolint:exhaustruct
+		Rid:             101,
+		Torrents:        map[string]json.RawMessage{},
+		Trackers:        map[string][]string{"https://b.example/announce": {"hash2"}},
+		TrackersRemoved: []string{"udp://a.example:6969/announce"},
+	}
+	state.Apply(deltaDelta)
+
+	trackers := state.GetMainData().Trackers
+
+	if len(trackers) != 1 {
+		t.Fatalf("expected 1 tracker, got %v", trackers)
+	}
+
+	if hashes := trackers["https://b.example/announce"]; len(hashes) != 1 || hashes[0] != "hash2" {
+		t.Errorf("expected the torrents of the tracker to be replaced, got %v", hashes)
+	}
+}
+
 func TestState_Reset(t *testing.T) {
 	t.Parallel()
 
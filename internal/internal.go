@@ -17,6 +17,29 @@ func IsValidHttpsURL(input string) bool {
 	return err == nil && u.Scheme == "https" && u.Host != ""
 }
 
+// NormalizeBaseURL returns the base URL of input, which can omit the scheme,
+// without trailing slash. It returns false when input is not a valid URL.
+func NormalizeBaseURL(input string) (string, bool) {
+	if !strings.Contains(input, "://") {
+		input = "http://" + input
+	}
+
+	input = strings.TrimSuffix(input, "/")
+
+	return input, IsValidURL(input)
+}
+
+// URLHost returns the lowercased host of a URL, without its port. It is
+// empty for invalid URLs.
+func URLHost(input string) string {
+	u, err := url.Parse(input)
+	if err != nil {
+		return ""
+	}
+
+	return strings.ToLower(u.Hostname())
+}
+
 func EnsureLeadingSlash(input *string) {
 	if !strings.HasPrefix(*input, "/") {
 		*input = "/" + *input
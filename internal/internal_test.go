@@ -74,6 +74,46 @@ func TestIsValidHttpsURL(t *testing.T) {
 	}
 }
 
+func TestNormalizeBaseURL(t *testing.T) {
+	t.Parallel()
+
+	testCases := [...]struct {
+		input    string
+		expected string
+		valid    bool
+	}{
+		{"https://192.168.1.10:8080/", "https://192.168.1.10:8080", true},
+		{"192.168.1.10:8080", "http://192.168.1.10:8080", true},
+		{"qbittorrent", "http://qbittorrent", true},
+		{"http://", "http:/", false},
+		{"", "http://", false},
+	}
+
+	for _, tc := range testCases {
+		result, valid := NormalizeBaseURL(tc.input)
+		if valid != tc.valid || (valid && result != tc.expected) {
+			t.Errorf("Expected %s to be %q (%v), but got %q (%v)", tc.input, tc.expected, tc.valid, result, valid)
+		}
+	}
+}
+
+func TestURLHost(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"https://Tracker.example:443/passkey/announce": "tracker.example",
+		"udp://open.example:1337/announce":             "open.example",
+		"** [DHT] **":                                  "",
+		"":                                             "",
+	}
+
+	for input, expected := range tests {
+		if result := URLHost(input); result != expected {
+			t.Errorf("Expected %s to be %q, but got %q", input, expected, result)
+		}
+	}
+}
+
 func TestEnsureLeadingSlash(t *testing.T) {
 	t.Parallel()
 
@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"os"
 	"strings"
+	"sync"
 )
 
 type Logger struct {
@@ -91,25 +92,41 @@ func (h *PrettyHandler) Handle(ctx context.Context, r slog.Record) error {
 	return nil
 }
 
-func SetLogLevel(logLevel string) string {
+// ParseLogLevel returns the name of the log level, INFO when unknown.
+func ParseLogLevel(logLevel string) string {
 	upperLogLevel := strings.ToUpper(logLevel)
-
-	level, found := LogLevels[upperLogLevel]
-	if !found {
-		upperLogLevel = "INFO"
-		level = LevelInfo
+	if _, found := LogLevels[upperLogLevel]; !found {
+		return "INFO"
 	}
 
-	opts := slog.HandlerOptions{ //nolint:exhaustruct
-		Level: level,
-	}
+	return upperLogLevel
+}
+
+func SetLogLevel(logLevel string) string {
+	upperLogLevel := ParseLogLevel(logLevel)
 
-	handler := NewPrettyHandler(os.Stdout, opts)
-	Log = &Logger{slog.New(handler)}
+	currentLevel.Set(LogLevels[upperLogLevel])
+
+	// The logger is only replaced once, the level of later calls applies to
+	// the goroutines already logging
+	if logger := prettyLogger(); Log != logger {
+		Log = logger
+	}
 
 	return upperLogLevel
 }
 
+// currentLevel is the level of the logger set by SetLogLevel.
+var currentLevel slog.LevelVar
+
+var prettyLogger = sync.OnceValue(func() *Logger {
+	opts := slog.HandlerOptions{ //nolint:exhaustruct
+		Level: &currentLevel,
+	}
+
+	return &Logger{slog.New(NewPrettyHandler(os.Stdout, opts))}
+})
+
 var Log *Logger
 
 func Trace(msg string) {
@@ -1,40 +1,128 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
+	API "qbit-exp/api"
 	app "qbit-exp/app"
 	logger "qbit-exp/logger"
+	prom "qbit-exp/prometheus"
 	"qbit-exp/qbit"
+)
+
+const (
+	probePath  string = "/probe"
+	reloadPath string = "/-/reload"
+
+	// configWatchInterval is how often the config file is checked for changes.
+	configWatchInterval = 5 * time.Second
+)
 
-	vmmetrics "github.com/VictoriaMetrics/metrics"
+// Exit codes of the exporter, from sysexits.h.
+const (
+	exitOK          int = 0
+	exitServerError int = 1
+	exitBanned      int = 75 // EX_TEMPFAIL
+	exitAuthError   int = 77 // EX_NOPERM
+	exitConfigError int = 78 // EX_CONFIG
 )
 
 func main() {
-	app.LoadEnv()
+	os.Exit(run())
+}
+
+// run starts the exporter and returns its exit code. It only returns when the
+// exporter can't start or stops serving.
+func run() int {
+	checkConfig, err := app.LoadEnv()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid configuration:\n"+err.Error())
+
+		return exitConfigError
+	}
 
-	if app.QBittorrent.APIKey == nil {
-		_ = qbit.Auth()
+	if checkConfig {
+		fmt.Println("The configuration is valid")
+
+		return exitOK
+	}
+
+	qbit.SetInstances(app.Instances)
+
+	err = qbit.Auth()
+	if err != nil {
+		logger.Error("Can't log in to qBittorrent: " + err.Error())
+
+		return exitCode(err)
+	}
+
+	collect := qbit.AllRequests
+
+	if app.Exporter.PollInterval > 0 {
+		// The scrapes only read the snapshot, they are not blocked by a reload
+		// waiting for the collection
+		poller := qbit.NewPoller(app.Exporter.PollInterval, app.Exporter.PollMaxAge, func(r *prom.Registry) error {
+			app.RLockCollection()
+			defer app.RUnlockCollection()
+
+			return qbit.AllRequests(r)
+		})
+		go poller.Run(context.Background())
+
+		collect = poller.Collect
 	}
 
 	metrics := func(w http.ResponseWriter, req *http.Request) {
-		metrics(w, req, qbit.AllRequests)
+		metrics(w, req, collect)
+	}
+
+	exporterPath := app.Exporter.Path
+
+	http.HandleFunc(exporterPath, lockSettings(basicAuth(metrics)))
+
+	if app.Exporter.Features.EnableProbe {
+		probe := func(w http.ResponseWriter, req *http.Request) {
+			probe(w, req, qbit.Probe)
+		}
+
+		http.HandleFunc(probePath, lockSettings(basicAuth(probe)))
 	}
-	if app.Exporter.BasicAuth != nil {
-		metrics = basicAuth(metrics)
+
+	if app.Exporter.ProcessMetricsPath != "" {
+		http.HandleFunc(app.Exporter.ProcessMetricsPath, lockSettings(basicAuth(processMetrics)))
 	}
 
-	http.HandleFunc(app.Exporter.Path, metrics)
+	http.HandleFunc(reloadPath, reloadAuth(func(w http.ResponseWriter, req *http.Request) {
+		reloadHandler(w, req, reload)
+	}))
 
 	http.HandleFunc("/healthz", healthz)
 
 	http.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
-		http.Redirect(w, req, app.Exporter.Path, http.StatusFound)
+		http.Redirect(w, req, exporterPath, http.StatusFound)
 	})
 
+	go func() {
+		hangups := make(chan os.Signal, 1)
+		signal.Notify(hangups, syscall.SIGHUP)
+
+		for range hangups {
+			logger.Info("Received SIGHUP, reloading the settings")
+
+			_ = reload()
+		}
+	}()
+
+	go app.WatchConfigFile(context.Background(), configWatchInterval, func() { _ = reload() })
+
 	addr := fmt.Sprintf("%s:%d", app.Exporter.Host, app.Exporter.Port)
 
 	logger.Info("Starting the exporter")
@@ -44,13 +132,33 @@ func main() {
 		ReadHeaderTimeout: 3 * time.Second,
 	}
 
-	err := server.ListenAndServe()
-	if err != nil {
-		panic(err)
+	err = server.ListenAndServe()
+	logger.Error("The exporter stopped serving: " + err.Error())
+
+	return exitCode(err)
+}
+
+// exitCode returns the exit code for an error stopping the exporter.
+func exitCode(err error) int {
+	var (
+		configError *app.ConfigError
+		authError   *API.AuthError
+		bannedError *API.BannedError
+	)
+
+	switch {
+	case errors.As(err, &configError):
+		return exitConfigError
+	case errors.As(err, &bannedError):
+		return exitBanned
+	case errors.As(err, &authError):
+		return exitAuthError
+	default:
+		return exitServerError
 	}
 }
 
-func metrics(w http.ResponseWriter, req *http.Request, allRequestsFunc func(*vmmetrics.Set) error) {
+func metrics(w http.ResponseWriter, req *http.Request, allRequestsFunc func(*prom.Registry) error) {
 	ip, _, err := net.SplitHostPort(req.RemoteAddr)
 
 	logMsg := "New request"
@@ -60,43 +168,175 @@ func metrics(w http.ResponseWriter, req *http.Request, allRequestsFunc func(*vmm
 
 	logger.Trace(logMsg)
 
-	metricsSet := vmmetrics.NewSet()
+	registry := prom.NewRegistry(nil)
 
-	err = allRequestsFunc(metricsSet)
+	// The scrape succeeds even when the metrics can't be collected, so that
+	// qbittorrent_up and the exporter metrics are kept
+	err = allRequestsFunc(registry)
 	if err != nil {
+		logger.Debug("Serving the metrics without qBittorrent metrics: " + err.Error())
+		prom.Down(registry)
+	}
+
+	if app.Exporter.Features.EnableProcessMetrics {
+		prom.BuildInfo(registry, app.Version(), app.EnabledFeatures())
+	}
+
+	format := writeMetrics(w, req, registry)
+
+	// The process metrics are only available in the Prometheus text format
+	if app.Exporter.Features.EnableProcessMetrics && format == prom.FormatText {
+		prom.WriteProcessMetrics(w)
+	}
+}
+
+// processMetrics serves the process and Go runtime metrics of the exporter,
+// with its build information. They are only available in the Prometheus text
+// format.
+func processMetrics(w http.ResponseWriter, _ *http.Request) {
+	registry := prom.NewRegistry(nil)
+	prom.BuildInfo(registry, app.Version(), app.EnabledFeatures())
+
+	w.Header().Set("Content-Type", prom.FormatText.ContentType())
+	prom.Write(w, registry, prom.FormatText)
+	prom.WriteProcessMetrics(w)
+}
+
+// probe scrapes the qBittorrent instance given by the target query parameter,
+// using the credentials of the module query parameter.
+func probe(w http.ResponseWriter, req *http.Request, probeFunc func(target string, module string, r *prom.Registry) error) {
+	target := req.URL.Query().Get("target")
+	module := req.URL.Query().Get("module")
+
+	logger.Trace(fmt.Sprintf("New probe request for %s (module %q)", target, module))
+
+	registry := prom.NewRegistry(nil)
+
+	err := probeFunc(target, module, registry)
+	if errors.Is(err, qbit.ErrInvalidTarget) || errors.Is(err, qbit.ErrUnknownModule) || errors.Is(err, qbit.ErrTargetNotAllowed) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	} else if err != nil {
 		http.Error(w, "", http.StatusServiceUnavailable)
 	} else {
-		metricsSet.WritePrometheus(w)
+		writeMetrics(w, req, registry)
 	}
 }
 
+// writeMetrics writes the metrics in the format negotiated from the Accept
+// header, and returns that format.
+func writeMetrics(w http.ResponseWriter, req *http.Request, registry *prom.Registry) prom.Format {
+	format := prom.NegotiateFormat(req.Header.Get("Accept"))
+
+	w.Header().Set("Content-Type", format.ContentType())
+	prom.Write(w, registry, format)
+
+	return format
+}
+
 // healthz reports server liveness without triggering a metrics collection.
 func healthz(w http.ResponseWriter, _ *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write([]byte("OK"))
 }
 
+// reload reads the settings again. The clients of the instances keep their
+// sync state, the probe clients are dropped with the previous credentials.
+func reload() error {
+	err := app.Reload(func() {
+		qbit.SetInstances(app.Instances)
+		qbit.ResetProbeClients()
+	})
+	if err != nil {
+		logger.Error("Invalid settings, keeping the current ones: " + err.Error())
+
+		return err
+	}
+
+	logger.Info("Settings reloaded")
+
+	return nil
+}
+
+// reloadHandler reloads the settings on POST requests.
+func reloadHandler(w http.ResponseWriter, req *http.Request, reloadFunc func() error) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	err := reloadFunc()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("OK"))
+}
+
+// lockSettings keeps the settings from being reloaded while h runs.
+func lockSettings(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		app.RLockSettings()
+		defer app.RUnlockSettings()
+
+		h(w, r)
+	}
+}
+
+// basicAuth checks the exporter credentials when basic auth is configured.
+// The settings must be locked.
 func basicAuth(h http.HandlerFunc) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		username, password, ok := r.BasicAuth()
-		if ok {
-			if username == app.Exporter.BasicAuth.Username && password == app.Exporter.BasicAuth.Password {
-				h.ServeHTTP(w, r)
+		if app.Exporter.BasicAuth == nil || validCredentials(r) {
+			h.ServeHTTP(w, r)
 
-				return
-			}
+			return
 		}
 
-		logErr := "Invalid auth"
+		unauthorized(w, r)
+	})
+}
+
+// reloadAuth checks the exporter credentials. Unlike basicAuth, requests are
+// forbidden when basic auth is not configured.
+func reloadAuth(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		app.RLockSettings()
+		configured := app.Exporter.BasicAuth != nil
+		valid := configured && validCredentials(r)
+		app.RUnlockSettings()
 
-		ip, _, err := net.SplitHostPort(r.RemoteAddr)
-		if err == nil {
-			logErr = fmt.Sprintf("%s from %s", logErr, ip)
+		switch {
+		case !configured:
+			http.Error(w, "Forbidden: the reload endpoint requires basic auth", http.StatusForbidden)
+		case !valid:
+			unauthorized(w, r)
+		default:
+			h(w, r)
 		}
+	}
+}
 
-		logger.Warn(logErr)
+func validCredentials(r *http.Request) bool {
+	username, password, ok := r.BasicAuth()
 
-		w.Header().Set("WWW-Authenticate", `Basic realm="restricted", charset="UTF-8"`)
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-	})
+	return ok && username == app.Exporter.BasicAuth.Username && password == app.Exporter.BasicAuth.Password
+}
+
+func unauthorized(w http.ResponseWriter, r *http.Request) {
+	logErr := "Invalid auth"
+
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err == nil {
+		logErr = fmt.Sprintf("%s from %s", logErr, ip)
+	}
+
+	logger.Warn(logErr)
+
+	w.Header().Set("WWW-Authenticate", `Basic realm="restricted", charset="UTF-8"`)
+	http.Error(w, "Unauthorized", http.StatusUnauthorized)
 }
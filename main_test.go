@@ -11,10 +11,11 @@ import (
 	"testing"
 	"time"
 
+	API "qbit-exp/api"
 	"qbit-exp/app"
 	"qbit-exp/logger"
-
-	vmmetrics "github.com/VictoriaMetrics/metrics"
+	prom "qbit-exp/prometheus"
+	"qbit-exp/qbit"
 )
 
 var buff = &bytes.Buffer{}
@@ -34,12 +35,16 @@ func TestMetricsFailureResponse(t *testing.T) {
 
 	rec := httptest.NewRecorder()
 
-	metrics(rec, req, func(_ *vmmetrics.Set) error {
+	metrics(rec, req, func(_ *prom.Registry) error {
 		return errors.New("mock error")
 	})
 
-	if status := rec.Code; status != http.StatusServiceUnavailable {
-		t.Errorf("expected status code 503, got %d", status)
+	if status := rec.Code; status != http.StatusOK {
+		t.Errorf("expected status code 200, got %d", status)
+	}
+
+	if body := rec.Body.String(); !strings.Contains(body, "qbittorrent_up 0\n") {
+		t.Errorf("expected qbittorrent_up 0 in\n%s", body)
 	}
 }
 
@@ -64,7 +69,7 @@ func TestMetricsReturnMetric(t *testing.T) {
 
 	rec := httptest.NewRecorder()
 
-	metrics(rec, req, func(registry *vmmetrics.Set) error {
+	metrics(rec, req, func(registry *prom.Registry) error {
 		qbittorrent_app_version := registry.GetOrCreateGauge(`qbittorrent_app_version{version="1.0"}`, nil)
 		qbittorrent_app_version.Set(1)
 
@@ -230,3 +235,235 @@ func TestBasicAuth_NoCredentials(t *testing.T) {
 		t.Errorf("expected WWW-Authenticate header %q, got %q", expectedHeader, authHeader)
 	}
 }
+
+func TestProbe(t *testing.T) {
+	tests := []struct {
+		name           string
+		probeErr       error
+		expectedStatus int
+	}{
+		{"Success", nil, http.StatusOK},
+		{"Invalid target", qbit.ErrInvalidTarget, http.StatusBadRequest},
+		{"Unknown module", qbit.ErrUnknownModule, http.StatusBadRequest},
+		{"Target not allowed", qbit.ErrTargetNotAllowed, http.StatusBadRequest},
+		{"Unreachable target", errors.New("mock error"), http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			req := httptest.NewRequestWithContext(ctx, http.MethodGet, "/probe?target=localhost:8080&module=box", nil)
+			rec := httptest.NewRecorder()
+
+			probe(rec, req, func(target string, module string, registry *prom.Registry) error {
+				if target != "localhost:8080" || module != "box" {
+					t.Errorf("expected target localhost:8080 and module box, got %s and %s", target, module)
+				}
+
+				registry.GetOrCreateGauge("qbittorrent_global_torrents", nil).Set(1)
+
+				return tt.probeErr
+			})
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("expected status code %d, got %d", tt.expectedStatus, rec.Code)
+			}
+
+			if tt.probeErr == nil && rec.Body.String() != "qbittorrent_global_torrents 1\n" {
+				t.Errorf("unexpected body %s", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestMetricsOpenMetrics(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	req := httptest.NewRequestWithContext(ctx, http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text;version=1.0.0,text/plain;q=0.5")
+
+	rec := httptest.NewRecorder()
+
+	metrics(rec, req, func(registry *prom.Registry) error {
+		registry.GetOrCreateGauge("qbittorrent_global_torrents", nil).Set(1)
+
+		return nil
+	})
+
+	if contentType := rec.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "application/openmetrics-text") {
+		t.Errorf("expected OpenMetrics content type, got %s", contentType)
+	}
+
+	if !strings.HasSuffix(rec.Body.String(), "# EOF\n") {
+		t.Errorf("expected body to end with # EOF, got %s", rec.Body.String())
+	}
+}
+
+func TestReloadHandler(t *testing.T) {
+	reloads := 0
+	reloadFunc := func() error {
+		reloads++
+
+		return nil
+	}
+
+	rec := httptest.NewRecorder()
+	reloadHandler(rec, httptest.NewRequestWithContext(t.Context(), http.MethodGet, reloadPath, nil), reloadFunc)
+
+	if rec.Code != http.StatusMethodNotAllowed || reloads != 0 {
+		t.Errorf("expected status code 405 without reload, got %d with %d reloads", rec.Code, reloads)
+	}
+
+	rec = httptest.NewRecorder()
+	reloadHandler(rec, httptest.NewRequestWithContext(t.Context(), http.MethodPost, reloadPath, nil), reloadFunc)
+
+	if rec.Code != http.StatusOK || reloads != 1 {
+		t.Errorf("expected status code 200 with a reload, got %d with %d reloads", rec.Code, reloads)
+	}
+
+	rec = httptest.NewRecorder()
+	reloadHandler(rec, httptest.NewRequestWithContext(t.Context(), http.MethodPost, reloadPath, nil), func() error {
+		return errors.New("exporter.port: must be an integer")
+	})
+
+	if rec.Code != http.StatusInternalServerError || !strings.Contains(rec.Body.String(), "exporter.port") {
+		t.Errorf("expected status code 500 with the error, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestReloadAuth(t *testing.T) {
+	handler := reloadAuth(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name      string
+		basicAuth *app.BasicAuth
+		username  string
+		expected  int
+	}{
+		{"Without basic auth", nil, "", http.StatusForbidden},
+		{"Invalid credentials", &app.BasicAuth{Username: "user", Password: "pass"}, "other", http.StatusUnauthorized},
+		{"Valid credentials", &app.BasicAuth{Username: "user", Password: "pass"}, "user", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		app.Exporter.BasicAuth = tt.basicAuth
+
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, reloadPath, nil)
+		if tt.username != "" {
+			req.SetBasicAuth(tt.username, "pass")
+		}
+
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+
+		if rec.Code != tt.expected {
+			t.Errorf("%s: expected status code %d, got %d", tt.name, tt.expected, rec.Code)
+		}
+	}
+
+	app.Exporter.BasicAuth = nil
+}
+
+func TestExitCode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"Config error", &app.ConfigError{Path: "EXPORTER_PORT", Message: "must be an integer"}, exitConfigError},
+		{"Joined config errors", errors.Join(&app.ConfigError{Path: "exporter.port", Message: "must be <= 65535"}), exitConfigError},
+		{"Auth error", &API.AuthError{Message: "wrong password"}, exitAuthError},
+		{"Banned", &API.BannedError{StatusCode: http.StatusForbidden}, exitBanned},
+		{"Server error", http.ErrServerClosed, exitServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := exitCode(tt.err); got != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestProcessMetrics(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	processMetrics(rec, httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/process", nil))
+
+	if contentType := rec.Header().Get("Content-Type"); contentType != prom.FormatText.ContentType() {
+		t.Errorf("expected the Prometheus text format, got %s", contentType)
+	}
+
+	for _, expected := range []string{"qbittorrent_exporter_build_info{", "go_goroutines "} {
+		if !strings.Contains(rec.Body.String(), expected) {
+			t.Errorf("expected %s in\n%s", expected, rec.Body.String())
+		}
+	}
+}
+
+func TestMetricsWithProcessMetrics(t *testing.T) {
+	app.Exporter.Features.EnableProcessMetrics = true
+	defer func() { app.Exporter.Features.EnableProcessMetrics = false }()
+
+	req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/metrics", nil)
+	rec := httptest.NewRecorder()
+
+	metrics(rec, req, func(registry *prom.Registry) error {
+		registry.GetOrCreateGauge("qbittorrent_global_torrents", nil).Set(3)
+
+		return nil
+	})
+
+	if contentType := rec.Header().Get("Content-Type"); contentType != prom.FormatText.ContentType() {
+		t.Errorf("expected the Prometheus text format, got %s", contentType)
+	}
+
+	for _, expected := range []string{"qbittorrent_global_torrents 3\n", "qbittorrent_exporter_build_info{", "go_memstats_alloc_bytes "} {
+		if !strings.Contains(rec.Body.String(), expected) {
+			t.Errorf("expected %s in\n%s", expected, rec.Body.String())
+		}
+	}
+}
+
+func TestMetricsWithProcessMetricsOpenMetrics(t *testing.T) {
+	app.Exporter.Features.EnableProcessMetrics = true
+	defer func() { app.Exporter.Features.EnableProcessMetrics = false }()
+
+	req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+
+	rec := httptest.NewRecorder()
+
+	metrics(rec, req, func(registry *prom.Registry) error {
+		registry.GetOrCreateGauge("qbittorrent_global_torrents", nil).Set(3)
+
+		return nil
+	})
+
+	if contentType := rec.Header().Get("Content-Type"); contentType != prom.FormatOpenMetrics.ContentType() {
+		t.Errorf("expected the OpenMetrics format, got %s", contentType)
+	}
+
+	body := rec.Body.String()
+	if !strings.HasSuffix(body, "# EOF\n") {
+		t.Errorf("expected the OpenMetrics output to end with # EOF, got\n%s", body)
+	}
+
+	if !strings.Contains(body, "qbittorrent_exporter_build_info{") {
+		t.Errorf("expected the build information in\n%s", body)
+	}
+
+	if strings.Contains(body, "go_memstats_alloc_bytes") {
+		t.Errorf("expected no process metrics in the OpenMetrics output\n%s", body)
+	}
+}
@@ -0,0 +1,125 @@
+package prom
+
+import (
+	"strings"
+
+	API "qbit-exp/api"
+)
+
+const (
+	metricNameCategory string = "category"
+	metricNameTag      string = "tag"
+	metricCatCategory  string = metricPrefix + separator + metricNameCategory + separator
+	metricCatTag       string = metricPrefix + separator + metricNameTag + separator
+
+	aggregateTorrents           string = "torrents"
+	aggregateSizeBytes          string = "size" + separator + torrentLabelBytes
+	aggregateDownloadedBytes    string = "downloaded" + separator + torrentLabelBytes
+	aggregateUploadedBytes      string = "uploaded" + separator + torrentLabelBytes
+	aggregateDownloadSpeedBytes string = "download_speed" + separator + torrentLabelBytes
+	aggregateUploadSpeedBytes   string = "upload_speed" + separator + torrentLabelBytes
+	aggregateRatio              string = "ratio"
+	aggregateStateTorrents      string = "state_torrents"
+)
+
+// aggregate sums the values of the torrents of a category or a tag.
+type aggregate struct {
+	torrents      int64
+	size          int64
+	downloaded    int64
+	uploaded      int64
+	downloadSpeed int64
+	uploadSpeed   int64
+	ratio         float64
+	states        map[string]int64
+}
+
+func (a *aggregate) add(torrent API.Info) {
+	a.torrents++
+	a.size += torrent.Size
+	a.downloaded += torrent.Downloaded
+	a.uploaded += torrent.Uploaded
+	a.downloadSpeed += torrent.Dlspeed
+	a.uploadSpeed += torrent.Upspeed
+	a.ratio += torrent.Ratio
+	a.states[torrent.State]++
+}
+
+// Aggregates registers the per-category and per-tag sums of the torrents.
+// The categories and tags without torrents are also exported.
+func Aggregates(torrents API.SliceInfo, mainData *API.MainData, r *Registry) {
+	categories := make(map[string]*aggregate, len(mainData.CategoryMap))
+	tags := make(map[string]*aggregate, len(mainData.Tags))
+
+	for _, category := range mainData.CategoryMap {
+		getAggregate(categories, category.Name)
+	}
+
+	for _, tag := range mainData.Tags {
+		getAggregate(tags, tag)
+	}
+
+	for _, torrent := range torrents {
+		getAggregate(categories, torrent.Category).add(torrent)
+
+		if torrent.Tags != "" {
+			for tag := range strings.SplitSeq(torrent.Tags, ", ") {
+				getAggregate(tags, tag).add(torrent)
+			}
+		}
+	}
+
+	registerAggregates(metricCatCategory, torrentLabelCategory, categories, r)
+	registerAggregates(metricCatTag, torrentLabelTag, tags, r)
+}
+
+func getAggregate(aggregates map[string]*aggregate, key string) *aggregate {
+	if a, exists := aggregates[key]; exists {
+		return a
+	}
+
+	a := &aggregate{states: make(map[string]int64)} //nolint:exhaustruct
+	aggregates[key] = a
+
+	return a
+}
+
+// registerAggregates registers the aggregates of a category or tag family.
+// The sums of the downloaded and uploaded bytes are gauges, since they
+// decrease when a torrent is removed.
+func registerAggregates(prefix, label string, aggregates map[string]*aggregate, r *Registry) {
+	labels := []string{label}
+	subject := "by " + label
+
+	gauges := GaugeList{
+		{prefix + aggregateTorrents, "The number of torrents " + subject, labels},
+		{prefix + aggregateSizeBytes, "The total size of the torrents " + subject + BytesHelper, labels},
+		{prefix + aggregateDownloadedBytes, "The data downloaded by the current torrents " + subject + BytesHelper, labels},
+		{prefix + aggregateUploadedBytes, "The data uploaded by the current torrents " + subject + BytesHelper, labels},
+		{prefix + aggregateDownloadSpeedBytes, "The download speed of the torrents " + subject + BytesHelper, labels},
+		{prefix + aggregateUploadSpeedBytes, "The upload speed of the torrents " + subject + BytesHelper, labels},
+		{prefix + aggregateRatio, "The mean ratio of the torrents " + subject, labels},
+		{prefix + aggregateStateTorrents, "The number of torrents " + subject + " and state", []string{label, torrentLabelState}},
+	}
+
+	metrics := registerGauge(&gauges, r)
+
+	for key, a := range aggregates {
+		keyLabels := map[string]string{label: key}
+
+		metrics[prefix+aggregateTorrents].With(keyLabels).Set(float64(a.torrents))
+		metrics[prefix+aggregateSizeBytes].With(keyLabels).Set(float64(a.size))
+		metrics[prefix+aggregateDownloadedBytes].With(keyLabels).Set(float64(a.downloaded))
+		metrics[prefix+aggregateUploadedBytes].With(keyLabels).Set(float64(a.uploaded))
+		metrics[prefix+aggregateDownloadSpeedBytes].With(keyLabels).Set(float64(a.downloadSpeed))
+		metrics[prefix+aggregateUploadSpeedBytes].With(keyLabels).Set(float64(a.uploadSpeed))
+
+		if a.torrents > 0 {
+			metrics[prefix+aggregateRatio].With(keyLabels).Set(a.ratio / float64(a.torrents))
+		}
+
+		for state, count := range a.states {
+			metrics[prefix+aggregateStateTorrents].With(map[string]string{label: key, torrentLabelState: state}).Set(float64(count))
+		}
+	}
+}
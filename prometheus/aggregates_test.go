@@ -0,0 +1,61 @@
+package prom
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	API "qbit-exp/api"
+)
+
+func TestAggregates(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry(nil)
+
+	torrents := API.SliceInfo{
+		{Name: "a", Category: "linux", Tags: "iso, seed", Size: 100, Uploaded: 300, Ratio: 3, State: "uploading", Upspeed: 10}, //nolint:exhaustruct
+		{Name: "b", Category: "linux", Tags: "iso", Size: 50, Downloaded: 25, Ratio: 1, State: "downloading", Dlspeed: 5},      //nolint:exhaustruct
+		{Name: "c", Size: 10, State: "downloading"}, //nolint:exhaustruct
+	}
+
+	mainData := &API.MainData{ //nolint:exhaustruct
+		CategoryMap: map[string]API.Category{
+			"linux": {Name: "linux"}, //nolint:exhaustruct
+			"empty": {Name: "empty"}, //nolint:exhaustruct
+		},
+		Tags: []string{"iso", "seed", "unused"},
+	}
+
+	Aggregates(torrents, mainData, registry)
+
+	var output bytes.Buffer
+
+	registry.WritePrometheus(&output)
+
+	for _, expected := range []string{
+		`qbittorrent_category_torrents{category="linux"} 2`,
+		`qbittorrent_category_torrents{category=""} 1`,
+		`qbittorrent_category_torrents{category="empty"} 0`,
+		`qbittorrent_category_size_bytes{category="linux"} 150`,
+		`qbittorrent_category_downloaded_bytes{category="linux"} 25`,
+		`qbittorrent_category_uploaded_bytes{category="linux"} 300`,
+		`qbittorrent_category_download_speed_bytes{category="linux"} 5`,
+		`qbittorrent_category_upload_speed_bytes{category="linux"} 10`,
+		`qbittorrent_category_ratio{category="linux"} 2`,
+		`qbittorrent_category_state_torrents{category="linux",state="uploading"} 1`,
+		`qbittorrent_category_state_torrents{category="",state="downloading"} 1`,
+		`qbittorrent_tag_torrents{tag="iso"} 2`,
+		`qbittorrent_tag_torrents{tag="seed"} 1`,
+		`qbittorrent_tag_torrents{tag="unused"} 0`,
+		`qbittorrent_tag_size_bytes{tag="iso"} 150`,
+	} {
+		if !strings.Contains(output.String(), expected+"\n") {
+			t.Errorf("expected %s in\n%s", expected, output.String())
+		}
+	}
+
+	if strings.Contains(output.String(), `qbittorrent_category_ratio{category="empty"}`) {
+		t.Errorf("expected no mean ratio without torrents, got\n%s", output.String())
+	}
+}
@@ -0,0 +1,116 @@
+package prom
+
+import (
+	"time"
+)
+
+// Metrics about the exporter itself.
+const (
+	metricNameExporter string = "exporter"
+	metricCatExporter  string = metricPrefix + separator + metricNameExporter + separator
+
+	exporterLabelEndpoint string = "endpoint"
+	exporterLabelType     string = "type"
+
+	// Values of the type label of the sync counter.
+	syncTypeFull  string = "full"
+	syncTypeDelta string = "delta"
+
+	qbittorrentExporterSnapshotAgeSeconds                   string = metricCatExporter + "snapshot_age_seconds"
+	qbittorrentExporterScrapeDurationSeconds                string = metricCatExporter + "scrape_duration_seconds"
+	qbittorrentExporterLastSuccessfulScrapeTimestampSeconds string = metricCatExporter + "last_successful_scrape_timestamp_seconds"
+	qbittorrentExporterRequestDurationSeconds               string = metricCatExporter + "request_duration_seconds"
+	qbittorrentExporterRequests                             string = metricCatExporter + "requests"
+	qbittorrentExporterRequestErrors                        string = metricCatExporter + "request_errors"
+	qbittorrentExporterSyncs                                string = metricCatExporter + "syncs"
+	qbittorrentExporterSyncTorrents                         string = metricCatExporter + "sync_torrents"
+	qbittorrentUp                                           string = metricPrefix + separator + "up"
+
+	helpQbittorrentExporterSnapshotAgeSeconds                   string = "The age of the served metrics, collected in the background (in seconds)"
+	helpQbittorrentExporterScrapeDurationSeconds                string = "The duration of the last collection of the metrics from qBittorrent (in seconds)"
+	helpQbittorrentExporterLastSuccessfulScrapeTimestampSeconds string = "The Unix timestamp of the last successful collection of the metrics from qBittorrent"
+	helpQbittorrentExporterRequestDurationSeconds               string = "The time spent on requests to the qBittorrent API endpoint during the last collection (in seconds)"
+	helpQbittorrentExporterRequests                             string = "The number of requests to the qBittorrent API endpoint"
+	helpQbittorrentExporterRequestErrors                        string = "The number of failed requests to the qBittorrent API endpoint"
+	helpQbittorrentExporterSyncs                                string = "The number of sync/maindata responses by type (full or delta)"
+	helpQbittorrentExporterSyncTorrents                         string = "The number of torrents in the delta sync state"
+	helpQbittorrentUp                                           string = "Whether qBittorrent could be scraped (1 for yes, 0 for no)"
+)
+
+// ScrapeStats are the statistics of the collections of an instance.
+type ScrapeStats struct {
+	// Duration is the duration of the last collection.
+	Duration time.Duration
+	// LastSuccess is the time of the last successful collection, zero
+	// without any.
+	LastSuccess time.Time
+	// Requests are the statistics of the requests by API endpoint, such as
+	// "sync/maindata".
+	Requests   map[string]RequestStats
+	FullSyncs  uint64
+	DeltaSyncs uint64
+	// Torrents is the number of torrents in the delta sync state.
+	Torrents int
+}
+
+// RequestStats are the statistics of the requests to an API endpoint.
+type RequestStats struct {
+	// Duration is the time spent on the endpoint during the last collection.
+	Duration time.Duration
+	Requests uint64
+	Errors   uint64
+}
+
+// SnapshotAge sets the age of the snapshot served to the scrape.
+func SnapshotAge(r *Registry, age time.Duration) {
+	newGauge(r, qbittorrentExporterSnapshotAgeSeconds, helpQbittorrentExporterSnapshotAgeSeconds).Set(age.Seconds())
+}
+
+// Up sets whether qBittorrent could be scraped.
+func Up(r *Registry, up bool) {
+	value := 0.0
+	if up {
+		value = 1
+	}
+
+	newGauge(r, qbittorrentUp, helpQbittorrentUp).Set(value)
+}
+
+// Down sets qbittorrent_up to 0, unless the collection already set it.
+func Down(r *Registry) {
+	if !r.hasSeries(qbittorrentUp) {
+		Up(r, false)
+	}
+}
+
+// Scrape registers the statistics of the collections of an instance.
+func Scrape(stats *ScrapeStats, r *Registry) {
+	newGauge(r, qbittorrentExporterScrapeDurationSeconds, helpQbittorrentExporterScrapeDurationSeconds).Set(stats.Duration.Seconds())
+
+	if !stats.LastSuccess.IsZero() {
+		newGauge(r, qbittorrentExporterLastSuccessfulScrapeTimestampSeconds, helpQbittorrentExporterLastSuccessfulScrapeTimestampSeconds).
+			Set(float64(stats.LastSuccess.UnixMilli()) / 1000)
+	}
+
+	if len(stats.Requests) > 0 {
+		labels := []string{exporterLabelEndpoint}
+
+		durations := newGaugeVec(r, qbittorrentExporterRequestDurationSeconds, helpQbittorrentExporterRequestDurationSeconds, labels)
+		requests := newCounterVec(r, qbittorrentExporterRequests+counterSuffix, helpQbittorrentExporterRequests, labels)
+		errors := newCounterVec(r, qbittorrentExporterRequestErrors+counterSuffix, helpQbittorrentExporterRequestErrors, labels)
+
+		for endpoint, request := range stats.Requests {
+			endpointLabels := map[string]string{exporterLabelEndpoint: endpoint}
+
+			durations.With(endpointLabels).Set(request.Duration.Seconds())
+			requests.With(endpointLabels).Set(request.Requests)
+			errors.With(endpointLabels).Set(request.Errors)
+		}
+	}
+
+	syncs := newCounterVec(r, qbittorrentExporterSyncs+counterSuffix, helpQbittorrentExporterSyncs, []string{exporterLabelType})
+	syncs.With(map[string]string{exporterLabelType: syncTypeFull}).Set(stats.FullSyncs)
+	syncs.With(map[string]string{exporterLabelType: syncTypeDelta}).Set(stats.DeltaSyncs)
+
+	newGauge(r, qbittorrentExporterSyncTorrents, helpQbittorrentExporterSyncTorrents).Set(float64(stats.Torrents))
+}
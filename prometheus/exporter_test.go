@@ -0,0 +1,89 @@
+package prom
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScrape(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry(nil)
+
+	Scrape(&ScrapeStats{
+		Duration:    1500 * time.Millisecond,
+		LastSuccess: time.Unix(1700000000, 0),
+		Requests: map[string]RequestStats{
+			"sync/maindata":     {Duration: 250 * time.Millisecond, Requests: 10, Errors: 2},
+			"torrents/trackers": {Duration: 0, Requests: 4, Errors: 0},
+		},
+		FullSyncs:  1,
+		DeltaSyncs: 9,
+		Torrents:   42,
+	}, registry)
+
+	var output bytes.Buffer
+
+	registry.WritePrometheus(&output)
+
+	for _, expected := range []string{
+		`qbittorrent_exporter_scrape_duration_seconds 1.5`,
+		`qbittorrent_exporter_last_successful_scrape_timestamp_seconds 1700000000`,
+		`qbittorrent_exporter_request_duration_seconds{endpoint="sync/maindata"} 0.25`,
+		`qbittorrent_exporter_requests_total{endpoint="sync/maindata"} 10`,
+		`qbittorrent_exporter_request_errors_total{endpoint="sync/maindata"} 2`,
+		`qbittorrent_exporter_request_errors_total{endpoint="torrents/trackers"} 0`,
+		`qbittorrent_exporter_syncs_total{type="full"} 1`,
+		`qbittorrent_exporter_syncs_total{type="delta"} 9`,
+		`qbittorrent_exporter_sync_torrents 42`,
+	} {
+		if !strings.Contains(output.String(), expected+"\n") {
+			t.Errorf("expected %s in\n%s", expected, output.String())
+		}
+	}
+}
+
+func TestScrapeWithoutSuccess(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry(nil)
+
+	Scrape(&ScrapeStats{}, registry) //nolint:exhaustruct
+
+	var output bytes.Buffer
+
+	registry.WritePrometheus(&output)
+
+	if strings.Contains(output.String(), "last_successful_scrape_timestamp") {
+		t.Errorf("expected no last successful scrape in\n%s", output.String())
+	}
+}
+
+func TestDown(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry(nil)
+	Down(registry)
+
+	labeled := NewRegistry(nil)
+	labeled.GetOrCreateGauge(`qbittorrent_up{instance="box1"}`, nil).Set(1)
+	Down(labeled)
+
+	for _, tt := range []struct {
+		registry *Registry
+		expected string
+	}{
+		{registry, "qbittorrent_up 0\n"},
+		{labeled, "qbittorrent_up{instance=\"box1\"} 1\n"},
+	} {
+		var output bytes.Buffer
+
+		tt.registry.WritePrometheus(&output)
+
+		if output.String() != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, output.String())
+		}
+	}
+}
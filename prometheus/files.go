@@ -0,0 +1,91 @@
+package prom
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+
+	API "qbit-exp/api"
+)
+
+const filesLabelFile string = "file"
+
+const (
+	qbittorrentTorrentFiles            string = metricCatTorrent + "files"
+	qbittorrentTorrentFileSizeBytes    string = metricCatTorrent + "file" + separator + torrentLabelSize + separator + torrentLabelBytes
+	qbittorrentTorrentFileProgress     string = metricCatTorrent + "file" + separator + torrentLabelProgress
+	qbittorrentTorrentFilePriority     string = metricCatTorrent + "file" + separator + "priority"
+	qbittorrentTorrentFileAvailability string = metricCatTorrent + "file" + separator + "availability"
+)
+
+const (
+	helpQbittorrentTorrentFiles            string = "The number of files of torrents"
+	helpQbittorrentTorrentFileSizeBytes    string = "The size of the files of torrents" + BytesHelper
+	helpQbittorrentTorrentFileProgress     string = "The progress of the files of torrents"
+	helpQbittorrentTorrentFilePriority     string = "The download priority of the files of torrents (0 means skipped)"
+	helpQbittorrentTorrentFileAvailability string = "The availability of the files of torrents (-1 when unknown)"
+)
+
+// TorrentFiles are the files of a torrent.
+type TorrentFiles struct {
+	Torrent API.Info
+	Files   []API.File
+}
+
+// Files registers the files metrics of the torrents. At most maxPerTorrent
+// files are exported per torrent: incomplete files first, then the largest.
+func Files(result []TorrentFiles, maxPerTorrent int, r *Registry) {
+	labels := baseTorrentLabelNames()
+	labelsWithFile := append(append([]string{}, labels...), filesLabelFile)
+
+	gauges := GaugeList{
+		{qbittorrentTorrentFiles, helpQbittorrentTorrentFiles, labels},
+		{qbittorrentTorrentFileSizeBytes, helpQbittorrentTorrentFileSizeBytes, labelsWithFile},
+		{qbittorrentTorrentFileProgress, helpQbittorrentTorrentFileProgress, labelsWithFile},
+		{qbittorrentTorrentFilePriority, helpQbittorrentTorrentFilePriority, labelsWithFile},
+		{qbittorrentTorrentFileAvailability, helpQbittorrentTorrentFileAvailability, labelsWithFile},
+	}
+
+	metrics := registerGauge(&gauges, r)
+
+	for _, torrentFiles := range result {
+		metrics[qbittorrentTorrentFiles].With(baseTorrentLabels(torrentFiles.Torrent)).Set(float64(len(torrentFiles.Files)))
+
+		for _, file := range limitFiles(torrentFiles.Files, maxPerTorrent) {
+			fileLabels := baseTorrentLabels(torrentFiles.Torrent)
+			fileLabels[filesLabelFile] = file.Name
+
+			metrics[qbittorrentTorrentFileSizeBytes].With(fileLabels).Set(float64(file.Size))
+			metrics[qbittorrentTorrentFileProgress].With(fileLabels).Set(math.Round(file.Progress*10000) / 10000)
+			metrics[qbittorrentTorrentFilePriority].With(fileLabels).Set(float64(file.Priority))
+			metrics[qbittorrentTorrentFileAvailability].With(fileLabels).Set(file.Availability)
+		}
+	}
+}
+
+// limitFiles returns at most maxFiles files, the incomplete ones first and
+// then the largest ones.
+func limitFiles(files []API.File, maxFiles int) []API.File {
+	if maxFiles < 1 || len(files) <= maxFiles {
+		return files
+	}
+
+	sorted := slices.Clone(files)
+
+	slices.SortFunc(sorted, func(a, b API.File) int {
+		aComplete, bComplete := a.Progress >= 1, b.Progress >= 1
+
+		if aComplete != bComplete {
+			if aComplete {
+				return 1
+			}
+
+			return -1
+		}
+
+		return cmp.Or(cmp.Compare(b.Size, a.Size), strings.Compare(a.Name, b.Name))
+	})
+
+	return sorted[:maxFiles]
+}
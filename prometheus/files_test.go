@@ -0,0 +1,61 @@
+package prom
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	API "qbit-exp/api"
+)
+
+func TestLimitFiles(t *testing.T) {
+	t.Parallel()
+
+	files := []API.File{
+		{Name: "complete-large", Size: 300, Progress: 1},     //nolint:exhaustruct
+		{Name: "incomplete-small", Size: 10, Progress: 0},    //nolint:exhaustruct
+		{Name: "incomplete-large", Size: 200, Progress: 0.5}, //nolint:exhaustruct
+	}
+
+	got := limitFiles(files, 2)
+	if len(got) != 2 || got[0].Name != "incomplete-large" || got[1].Name != "incomplete-small" {
+		t.Errorf("expected the incomplete files first, got %+v", got)
+	}
+
+	if got := limitFiles(files, 5); len(got) != 3 || got[0].Name != "complete-large" {
+		t.Errorf("expected the files to be kept in order under the limit, got %+v", got)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry(nil)
+
+	Files([]TorrentFiles{{
+		Torrent: API.Info{Name: "show"}, //nolint:exhaustruct
+		Files: []API.File{
+			{Name: "s01/e01.mkv", Size: 1024, Progress: 0.25, Priority: 1, Availability: 1.5}, //nolint:exhaustruct
+			{Name: "s01/e02.mkv", Size: 2048, Progress: 0, Priority: 0, Availability: -1},     //nolint:exhaustruct
+		},
+	}}, 1, registry)
+
+	var output bytes.Buffer
+
+	registry.WritePrometheus(&output)
+
+	for _, expected := range []string{
+		`qbittorrent_torrent_files{name="show"} 2`,
+		`qbittorrent_torrent_file_size_bytes{file="s01/e02.mkv",name="show"} 2048`,
+		`qbittorrent_torrent_file_priority{file="s01/e02.mkv",name="show"} 0`,
+		`qbittorrent_torrent_file_availability{file="s01/e02.mkv",name="show"} -1`,
+	} {
+		if !strings.Contains(output.String(), expected+"\n") {
+			t.Errorf("expected %s in\n%s", expected, output.String())
+		}
+	}
+
+	if strings.Contains(output.String(), "s01/e01.mkv") {
+		t.Errorf("expected files beyond the limit to be skipped, got\n%s", output.String())
+	}
+}
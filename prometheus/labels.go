@@ -0,0 +1,153 @@
+package prom
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	API "qbit-exp/api"
+	"qbit-exp/app"
+	"qbit-exp/logger"
+
+	"github.com/VictoriaMetrics/metrics"
+)
+
+// LabelInstance identifies the qBittorrent instance a series comes from. It is
+// not named instance, which Prometheus sets to the scraped target.
+const LabelInstance string = metricPrefix + separator + "instance"
+
+// Registry holds the series registered by the collectors. The series created
+// through the registry get its labels and are rewritten by its relabel
+// config.
+type Registry struct {
+	*metrics.Set
+
+	// labels are added to every series, unless the series already sets them.
+	labels map[string]string
+	// relabeler rewrites the series before the labels are added, nil
+	// without relabel config.
+	relabeler *relabeler
+
+	mu sync.Mutex
+	// included are the registries written along with the registry, see
+	// Include.
+	included []*Registry
+	// owners holds the series each relabeled series comes from, and collided
+	// the relabeled series that several series were rewritten to.
+	owners   map[string]string
+	collided map[string]struct{}
+}
+
+// NewRegistry returns a registry whose series all get labels.
+func NewRegistry(labels map[string]string) *Registry {
+	return NewRelabeledRegistry(labels, nil, nil)
+}
+
+// NewRelabeledRegistry returns a registry whose series are rewritten by config
+// when they are created, then get labels. The per-torrent series are matched
+// to the torrents by their hash label when set, by their name label otherwise.
+// When several series are rewritten to the same one, it is dropped.
+func NewRelabeledRegistry(labels map[string]string, config *app.RelabelConfig, torrents func() API.SliceInfo) *Registry {
+	r := &Registry{
+		Set:       metrics.NewSet(),
+		labels:    labels,
+		relabeler: nil,
+		mu:        sync.Mutex{},
+		included:  nil,
+		owners:    nil,
+		collided:  nil,
+	}
+
+	if config != nil {
+		r.relabeler = newRelabeler(config, torrents)
+		r.owners = make(map[string]string)
+		r.collided = make(map[string]struct{})
+	}
+
+	return r
+}
+
+// Include writes the series of src along with those of r, without copying
+// them. src must not change anymore.
+func (r *Registry) Include(src *Registry) {
+	r.mu.Lock()
+	r.included = append(r.included, src)
+	r.mu.Unlock()
+
+	r.RegisterMetricsWriter(src.WritePrometheus)
+}
+
+// series returns the name of a series of r with the labels and relabel config
+// of r applied, and false when the series is dropped.
+func (r *Registry) series(name string, labels map[string]string) (string, bool) {
+	if r.relabeler == nil {
+		return metricWithLabels(name, r.withLabels(labels)), true
+	}
+
+	relabeled, keep := r.relabeler.relabel(name, labels)
+	if !keep {
+		return "", false
+	}
+
+	original := metricWithLabels(name, labels)
+	series := metricWithLabels(name, r.withLabels(relabeled))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.collided[series]; exists {
+		return "", false
+	}
+
+	owner, exists := r.owners[series]
+	if !exists || owner == original {
+		r.owners[series] = original
+
+		return series, true
+	}
+
+	r.collided[series] = struct{}{}
+	r.UnregisterMetric(series)
+
+	logger.Error(fmt.Sprintf("Dropping %s: the relabel config rewrites both %s and %s to it", series, owner, original))
+
+	return "", false
+}
+
+// withLabels returns labels with the labels of r they don't set.
+func (r *Registry) withLabels(labels map[string]string) map[string]string {
+	if len(r.labels) == 0 {
+		return labels
+	}
+
+	merged := maps.Clone(labels)
+	if merged == nil {
+		merged = make(map[string]string, len(r.labels))
+	}
+
+	for key, value := range r.labels {
+		if _, exists := merged[key]; !exists {
+			merged[key] = value
+		}
+	}
+
+	return merged
+}
+
+// hasSeries tells whether r, or one of the registries it includes, has a
+// series of the family.
+func (r *Registry) hasSeries(family string) bool {
+	if slices.ContainsFunc(r.ListMetricNames(), func(name string) bool {
+		return name == family || strings.HasPrefix(name, family+"{")
+	}) {
+		return true
+	}
+
+	r.mu.Lock()
+	included := slices.Clone(r.included)
+	r.mu.Unlock()
+
+	return slices.ContainsFunc(included, func(src *Registry) bool { return src.hasSeries(family) })
+}
@@ -0,0 +1,85 @@
+package prom
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestNewRegistryLabels(t *testing.T) {
+	t.Parallel()
+
+	set := NewRegistry(map[string]string{LabelInstance: "box1"})
+
+	newGauge(set, qbittorrentGlobalTorrents, helpqbittorrentGlobalTorrents).Set(3)
+	newGaugeVec(set, qbittorrentTorrentRatio, helpQbittorrentTorrentRatio, nil).With(map[string]string{labelName: "torrent"}).Set(1.5)
+	newGaugeVec(set, qbittorrentTorrentRatio, helpQbittorrentTorrentRatio, nil).With(map[string]string{LabelInstance: "other"}).Set(2)
+
+	var output bytes.Buffer
+
+	set.WritePrometheus(&output)
+
+	for _, expected := range []string{
+		`qbittorrent_global_torrents{qbittorrent_instance="box1"} 3`,
+		`qbittorrent_torrent_ratio{name="torrent",qbittorrent_instance="box1"} 1.5`,
+		`qbittorrent_torrent_ratio{qbittorrent_instance="other"} 2`,
+	} {
+		if !strings.Contains(output.String(), expected+"\n") {
+			t.Errorf("expected %s in\n%s", expected, output.String())
+		}
+	}
+}
+
+func TestIncludeKeepsCounters(t *testing.T) {
+	t.Parallel()
+
+	src := NewRegistry(map[string]string{LabelInstance: "box1"})
+	newCounter(src, qbittorrentGlobalAlltimeUploadedBytes+counterSuffix, helpqbittorrentGlobalAlltimeUploadedBytes).Set(123456789012)
+
+	dst := NewRegistry(nil)
+	dst.Include(src)
+
+	var output bytes.Buffer
+
+	WritePrometheus(&output, dst)
+
+	expected := "# TYPE qbittorrent_global_alltime_uploaded_bytes_total counter\n" + //nolint:misspell
+		`qbittorrent_global_alltime_uploaded_bytes_total{qbittorrent_instance="box1"} 123456789012` + "\n" //nolint:misspell
+	if !strings.Contains(output.String(), expected) {
+		t.Errorf("expected\n%s\nin\n%s", expected, output.String())
+	}
+}
+
+func TestIncludeGroupsFamilies(t *testing.T) {
+	t.Parallel()
+
+	dst := NewRegistry(nil)
+
+	for _, instance := range []string{"box1", "box2"} {
+		src := NewRegistry(map[string]string{LabelInstance: instance})
+		Up(src, true)
+		newGauge(src, qbittorrentGlobalTorrents, helpqbittorrentGlobalTorrents).Set(3)
+		dst.Include(src)
+	}
+
+	var output bytes.Buffer
+
+	WritePrometheus(&output, dst)
+
+	if count := strings.Count(output.String(), "# TYPE "+qbittorrentUp+" "); count != 1 {
+		t.Errorf("expected the qbittorrent_up family once, got %d times in\n%s", count, output.String())
+	}
+
+	expected := "# TYPE qbittorrent_up gauge\n" +
+		`qbittorrent_up{qbittorrent_instance="box1"} 1` + "\n" +
+		`qbittorrent_up{qbittorrent_instance="box2"} 1` + "\n"
+	if !strings.Contains(output.String(), expected) {
+		t.Errorf("expected\n%s\nin\n%s", expected, output.String())
+	}
+
+	Down(dst)
+
+	if strings.Contains(output.String(), "qbittorrent_up 0") || len(dst.ListMetricNames()) != 0 {
+		t.Error("expected qbittorrent_up of the included sets to be found")
+	}
+}
@@ -0,0 +1,46 @@
+package prom
+
+import (
+	"strconv"
+
+	API "qbit-exp/api"
+)
+
+const (
+	metricNameLog string = "log"
+	metricCatLog  string = metricPrefix + separator + metricNameLog + separator
+
+	logLabelSeverity string = "severity"
+
+	qbittorrentLogEntries     string = metricCatLog + "entries"
+	qbittorrentLogBannedPeers string = metricCatLog + "banned_peers"
+
+	helpQbittorrentLogEntries     string = "The number of main log entries by severity"
+	helpQbittorrentLogBannedPeers string = "The number of peers blocked in the peer log"
+)
+
+// logSeverities are the label values of the log/main entry types.
+var logSeverities = map[int64]string{
+	API.LogTypeNormal:   "normal",
+	API.LogTypeInfo:     "info",
+	API.LogTypeWarning:  "warning",
+	API.LogTypeCritical: "critical",
+}
+
+// Log registers the counters of the log entries seen since the exporter
+// started.
+func Log(entriesByType map[int64]uint64, bannedPeers uint64, r *Registry) {
+	entries := newCounterVec(r, qbittorrentLogEntries+counterSuffix, helpQbittorrentLogEntries, []string{logLabelSeverity})
+
+	for logType, severity := range logSeverities {
+		entries.With(map[string]string{logLabelSeverity: severity}).Set(entriesByType[logType])
+	}
+
+	for logType, count := range entriesByType {
+		if _, known := logSeverities[logType]; !known {
+			entries.With(map[string]string{logLabelSeverity: strconv.FormatInt(logType, 10)}).Set(count)
+		}
+	}
+
+	newCounter(r, qbittorrentLogBannedPeers+counterSuffix, helpQbittorrentLogBannedPeers).Set(bannedPeers)
+}
@@ -0,0 +1,32 @@
+package prom
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	API "qbit-exp/api"
+)
+
+func TestLog(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry(nil)
+
+	Log(map[int64]uint64{API.LogTypeCritical: 3, 16: 1}, 7, registry)
+
+	var output bytes.Buffer
+
+	registry.WritePrometheus(&output)
+
+	for _, expected := range []string{
+		`qbittorrent_log_entries_total{severity="critical"} 3`,
+		`qbittorrent_log_entries_total{severity="normal"} 0`,
+		`qbittorrent_log_entries_total{severity="16"} 1`,
+		`qbittorrent_log_banned_peers_total 7`,
+	} {
+		if !strings.Contains(output.String(), expected+"\n") {
+			t.Errorf("expected %s in\n%s", expected, output.String())
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package prom
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Metric types written in the TYPE metadata.
+const (
+	typeGauge   string = "gauge"
+	typeCounter string = "counter"
+
+	// typeCreated marks the creation timestamps of counters. They are only
+	// written as part of their counter in the OpenMetrics format.
+	typeCreated string = "created"
+)
+
+type metadata struct {
+	help       string
+	metricType string
+}
+
+// metadataByName holds the HELP and TYPE of every metric family created by
+// this package. Metric names are constants, so the registry is shared by all
+// the sets.
+var (
+	metadataMu     sync.RWMutex
+	metadataByName = make(map[string]metadata)
+)
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func registerMetadata(name, help, metricType string) {
+	metadataMu.RLock()
+	existing, exists := metadataByName[name]
+	metadataMu.RUnlock()
+
+	if exists && existing.help == help && existing.metricType == metricType {
+		return
+	}
+
+	metadataMu.Lock()
+	defer metadataMu.Unlock()
+
+	metadataByName[name] = metadata{help: help, metricType: metricType}
+}
+
+func getMetadata(name string) (metadata, bool) {
+	metadataMu.RLock()
+	defer metadataMu.RUnlock()
+
+	m, exists := metadataByName[name]
+
+	return m, exists
+}
+
+// WritePrometheus writes the metrics of set in the Prometheus text format,
+// with the HELP and TYPE metadata of each metric family.
+func WritePrometheus(w io.Writer, set *Registry) {
+	writer := bufio.NewWriter(w)
+
+	for _, f := range parseFamilies(set) {
+		m, exists := getMetadata(f.name)
+		if exists && m.metricType == typeCreated {
+			continue
+		}
+
+		if exists {
+			_, _ = writer.WriteString("# HELP " + f.name + " " + helpEscaper.Replace(m.help) + "\n")
+			_, _ = writer.WriteString("# TYPE " + f.name + " " + m.metricType + "\n")
+		}
+
+		for _, s := range f.samples {
+			writeSample(writer, s.name, s.labels, s.value)
+		}
+	}
+
+	_ = writer.Flush()
+}
@@ -0,0 +1,92 @@
+package prom
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	API "qbit-exp/api"
+)
+
+func TestWritePrometheusMetadata(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry(nil)
+
+	Preference(&API.Preferences{
+		MaxActiveDownloads: 5,
+		MaxActiveUploads:   3,
+		MaxActiveTorrents:  10,
+		DlLimit:            0,
+		UpLimit:            0,
+		AltDlLimit:         0,
+		AltUpLimit:         0,
+	}, registry)
+
+	version := []byte("v5.0.2")
+	Version(&version, registry)
+
+	var output bytes.Buffer
+
+	WritePrometheus(&output, registry)
+
+	expected := "# HELP qbittorrent_app_version The current qBittorrent version\n" +
+		"# TYPE qbittorrent_app_version gauge\n" +
+		"qbittorrent_app_version{version=\"v5.0.2\"} 1\n"
+	if !strings.Contains(output.String(), expected) {
+		t.Errorf("expected\n%s\nin\n%s", expected, output.String())
+	}
+
+	expected = "# HELP qbittorrent_global_max_active_downloads The max number of downloads allowed\n" +
+		"# TYPE qbittorrent_global_max_active_downloads gauge\n" +
+		"qbittorrent_global_max_active_downloads 5\n"
+	if !strings.Contains(output.String(), expected) {
+		t.Errorf("expected\n%s\nin\n%s", expected, output.String())
+	}
+
+	if count := strings.Count(output.String(), "# HELP "); count != 8 {
+		t.Errorf("expected 8 HELP lines, got %d", count)
+	}
+}
+
+func TestWritePrometheusOnceByFamily(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry(nil)
+
+	gauge := newGaugeVec(registry, qbittorrentGlobalTags, helpqbittorrentGlobalTags, []string{torrentLabelTag})
+	gauge.With(map[string]string{torrentLabelTag: "tag1"}).Set(1)
+	gauge.With(map[string]string{torrentLabelTag: "tag2"}).Set(1)
+
+	var output bytes.Buffer
+
+	WritePrometheus(&output, registry)
+
+	if count := strings.Count(output.String(), "# TYPE qbittorrent_global_tags gauge\n"); count != 1 {
+		t.Errorf("expected metadata to be written once, got %d times in\n%s", count, output.String())
+	}
+}
+
+func TestWritePrometheusWithoutMetadata(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry(nil)
+	registry.GetOrCreateGauge("unknown_metric", nil).Set(1)
+
+	var output bytes.Buffer
+
+	WritePrometheus(&output, registry)
+
+	if output.String() != "unknown_metric 1\n" {
+		t.Errorf("expected no metadata for unknown metrics, got\n%s", output.String())
+	}
+}
+
+func TestHelpEscaper(t *testing.T) {
+	t.Parallel()
+
+	got := helpEscaper.Replace("line\\one\nline two")
+	if got != `line\\one\nline two` {
+		t.Errorf("unexpected escaped help %q", got)
+	}
+}
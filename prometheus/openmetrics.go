@@ -0,0 +1,221 @@
+package prom
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"mime"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Format is an exposition format negotiated with the scraper.
+type Format int
+
+const (
+	FormatText Format = iota
+	FormatOpenMetrics
+)
+
+const (
+	contentTypeText        string = "text/plain; version=0.0.4; charset=utf-8"
+	contentTypeOpenMetrics string = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+	mediaTypeOpenMetrics string = "application/openmetrics-text"
+)
+
+// units are inferred from the metric name suffix.
+var units = [...]string{torrentLabelBytes, "seconds"}
+
+var openMetricsHelpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+// NegotiateFormat returns FormatOpenMetrics when the Accept header allows
+// application/openmetrics-text, FormatText otherwise.
+func NegotiateFormat(accept string) Format {
+	for mediaRange := range strings.SplitSeq(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil || mediaType != mediaTypeOpenMetrics {
+			continue
+		}
+
+		if q, exists := params["q"]; exists {
+			quality, err := strconv.ParseFloat(q, 64)
+			if err != nil || quality <= 0 {
+				continue
+			}
+		}
+
+		return FormatOpenMetrics
+	}
+
+	return FormatText
+}
+
+// ContentType returns the Content-Type header of the format.
+func (f Format) ContentType() string {
+	if f == FormatOpenMetrics {
+		return contentTypeOpenMetrics
+	}
+
+	return contentTypeText
+}
+
+// Write writes the metrics of set in the given format.
+func Write(w io.Writer, set *Registry, format Format) {
+	if format == FormatOpenMetrics {
+		WriteOpenMetrics(w, set)
+
+		return
+	}
+
+	WritePrometheus(w, set)
+}
+
+type sample struct {
+	name   string
+	labels string
+	value  string
+}
+
+type family struct {
+	name    string
+	samples []sample
+}
+
+// WriteOpenMetrics writes the metrics of set in the OpenMetrics text format.
+// Counters are written with their `_created` sample when known. Since a
+// counter family has the name of its legacy gauge, legacy gauges are skipped.
+func WriteOpenMetrics(w io.Writer, set *Registry) {
+	families := parseFamilies(set)
+
+	byName := make(map[string]*family, len(families))
+	for i := range families {
+		byName[families[i].name] = &families[i]
+	}
+
+	writer := bufio.NewWriter(w)
+
+	for _, f := range families {
+		m, exists := getMetadata(f.name)
+		if exists && m.metricType == typeCreated {
+			continue
+		}
+
+		if _, hasCounter := byName[f.name+counterSuffix]; hasCounter && isLegacyGauge(f.name) {
+			continue
+		}
+
+		name := f.name
+		metricType := "unknown"
+		help := ""
+
+		if exists {
+			metricType = m.metricType
+			help = m.help
+		}
+
+		var created map[string]string
+
+		if metricType == typeCounter {
+			name = strings.TrimSuffix(name, counterSuffix)
+
+			if createdFamily, exists := byName[name+createdSuffix]; exists {
+				created = make(map[string]string, len(createdFamily.samples))
+				for _, s := range createdFamily.samples {
+					created[s.labels] = s.value
+				}
+			}
+		}
+
+		_, _ = writer.WriteString("# TYPE " + name + " " + metricType + "\n")
+
+		if unit := metricUnit(name); unit != "" {
+			_, _ = writer.WriteString("# UNIT " + name + " " + unit + "\n")
+		}
+
+		if help != "" {
+			_, _ = writer.WriteString("# HELP " + name + " " + openMetricsHelpEscaper.Replace(help) + "\n")
+		}
+
+		for _, s := range f.samples {
+			writeSample(writer, s.name, s.labels, s.value)
+
+			if value, exists := created[s.labels]; exists {
+				writeSample(writer, name+createdSuffix, s.labels, value)
+			}
+		}
+	}
+
+	_, _ = writer.WriteString("# EOF\n")
+	_ = writer.Flush()
+}
+
+func writeSample(writer *bufio.Writer, name, labels, value string) {
+	_, _ = writer.WriteString(name)
+
+	if labels != "" {
+		_, _ = writer.WriteString("{" + labels + "}")
+	}
+
+	_, _ = writer.WriteString(" " + value + "\n")
+}
+
+// isLegacyGauge reports whether name is the legacy gauge of a counter, which
+// would clash with the counter family name.
+func isLegacyGauge(name string) bool {
+	counter, exists := getMetadata(name + counterSuffix)
+
+	return exists && counter.metricType == typeCounter
+}
+
+// metricUnit returns the unit of a metric family, inferred from its suffix.
+func metricUnit(name string) string {
+	for _, unit := range units {
+		if strings.HasSuffix(name, separator+unit) {
+			return unit
+		}
+	}
+
+	return ""
+}
+
+// parseFamilies parses the series of set, grouped by metric family and
+// sorted by family name. The series of the sets included in set are grouped
+// with those of set.
+func parseFamilies(set *Registry) []family {
+	var output bytes.Buffer
+
+	set.WritePrometheus(&output)
+
+	var families []family
+
+	indexes := make(map[string]int)
+
+	for line := range strings.SplitSeq(output.String(), "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		sep := strings.LastIndexByte(line, ' ')
+		if sep == -1 {
+			continue
+		}
+
+		name, labels, _ := strings.Cut(line[:sep], "{")
+		s := sample{name: name, labels: strings.TrimSuffix(labels, "}"), value: line[sep+1:]}
+
+		i, exists := indexes[name]
+		if !exists {
+			i = len(families)
+			indexes[name] = i
+			families = append(families, family{name: name, samples: nil})
+		}
+
+		families[i].samples = append(families[i].samples, s)
+	}
+
+	slices.SortStableFunc(families, func(a, b family) int { return strings.Compare(a.name, b.name) })
+
+	return families
+}
@@ -0,0 +1,129 @@
+package prom
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		accept   string
+		expected Format
+	}{
+		{"", FormatText},
+		{"text/plain", FormatText},
+		{"application/openmetrics-text", FormatOpenMetrics},
+		{"application/openmetrics-text;version=1.0.0;q=0.5,text/plain;q=0.4", FormatOpenMetrics},
+		{"text/plain;version=0.0.4;q=0.9, application/openmetrics-text;q=0", FormatText},
+		{"*/*", FormatText},
+	}
+
+	for _, tt := range tests {
+		if got := NegotiateFormat(tt.accept); got != tt.expected {
+			t.Errorf("NegotiateFormat(%q) = %d, expected %d", tt.accept, got, tt.expected)
+		}
+	}
+}
+
+func TestWriteOpenMetricsGauge(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry(nil)
+	newGauge(registry, qbittorrentGlobalFreeSpaceOnDiskBytes, helpqbittorrentGlobalFreeSpaceOnDisk).Set(42)
+
+	var output bytes.Buffer
+
+	WriteOpenMetrics(&output, registry)
+
+	expected := "# TYPE qbittorrent_global_free_space_on_disk_bytes gauge\n" +
+		"# UNIT qbittorrent_global_free_space_on_disk_bytes bytes\n" +
+		"# HELP qbittorrent_global_free_space_on_disk_bytes " + helpqbittorrentGlobalFreeSpaceOnDisk + "\n" +
+		"qbittorrent_global_free_space_on_disk_bytes 42\n" +
+		"# EOF\n"
+	if output.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, output.String())
+	}
+}
+
+func TestWriteOpenMetricsCounter(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry(nil)
+
+	labels := map[string]string{"name": "torrent"}
+	counter := newCounterVec(registry, qbittorrentTorrentTotalDownloadedBytes+counterSuffix, helpQbittorrentTorrentTotalDownloadedBytes, nil)
+	counter.WithCreated(labels, 1700000000).Set(1024)
+	newGaugeVec(registry, qbittorrentTorrentTotalDownloadedBytes, helpQbittorrentTorrentTotalDownloadedBytes, nil).With(labels).Set(1024)
+
+	var output bytes.Buffer
+
+	WriteOpenMetrics(&output, registry)
+
+	family := qbittorrentTorrentTotalDownloadedBytes
+
+	if !strings.Contains(output.String(), "# TYPE "+family+" counter\n") {
+		t.Errorf("expected counter family %s in\n%s", family, output.String())
+	}
+
+	if !strings.Contains(output.String(), "# UNIT "+family+" bytes\n") {
+		t.Errorf("expected unit of %s in\n%s", family, output.String())
+	}
+
+	expected := family + "_total{name=\"torrent\"} 1024\n" + family + "_created{name=\"torrent\"} "
+	if !strings.Contains(output.String(), expected) {
+		t.Errorf("expected\n%s\nin\n%s", expected, output.String())
+	}
+
+	if strings.Contains(output.String(), family+"{") || strings.Contains(output.String(), "gauge") {
+		t.Errorf("expected legacy gauge to be skipped in\n%s", output.String())
+	}
+
+	if !strings.HasSuffix(output.String(), "# EOF\n") {
+		t.Errorf("expected output to end with # EOF, got\n%s", output.String())
+	}
+}
+
+func TestWriteOpenMetricsUnknown(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry(nil)
+	registry.GetOrCreateGauge("unknown_metric", nil).Set(1)
+
+	var output bytes.Buffer
+
+	WriteOpenMetrics(&output, registry)
+
+	expected := "# TYPE unknown_metric unknown\nunknown_metric 1\n# EOF\n"
+	if output.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, output.String())
+	}
+}
+
+func TestWritePrometheusSkipsCreated(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry(nil)
+
+	counter := newCounterVec(registry, qbittorrentTorrentTotalUploadedBytes+counterSuffix, helpQbittorrentTorrentTotalUploadedBytes, nil)
+	counter.WithCreated(map[string]string{"name": "torrent"}, 1700000000).Set(1)
+
+	var output bytes.Buffer
+
+	WritePrometheus(&output, registry)
+
+	if strings.Contains(output.String(), createdSuffix) {
+		t.Errorf("expected no created series in text format, got\n%s", output.String())
+	}
+}
+
+func TestOpenMetricsHelpEscaper(t *testing.T) {
+	t.Parallel()
+
+	got := openMetricsHelpEscaper.Replace("line\\one\n\"line two\"")
+	if got != `line\\one\n\"line two\"` {
+		t.Errorf("unexpected escaped help %q", got)
+	}
+}
@@ -0,0 +1,59 @@
+package prom
+
+import (
+	API "qbit-exp/api"
+)
+
+const (
+	// labelBucket tells the series summing several torrents apart from those
+	// of a torrent, which have no such label. A torrent can be named "other".
+	labelBucket string = "bucket"
+
+	// otherTorrentsBucket is the bucket label of the series summing the
+	// torrents outside of the top torrents.
+	otherTorrentsBucket string = "other"
+
+	qbittorrentTorrentOtherTorrents     string = metricCatTorrent + otherTorrentsBucket + separator + torrentLabelTorrents
+	helpQbittorrentTorrentOtherTorrents string = "The number of torrents summed in the series with the bucket \"" + otherTorrentsBucket + "\""
+)
+
+// OtherTorrents registers the per-torrent gauges which can be summed, with the
+// bucket "other" and no name, for the torrents outside of the top torrents.
+// The counters are left out: a torrent entering the top torrents would reset
+// them.
+func OtherTorrents(others API.SliceInfo, r *Registry) {
+	labels := []string{labelBucket}
+
+	gauges := GaugeList{
+		{qbittorrentTorrentDownloadSpeedBytes, helpQbittorrentTorrentDownloadSpeedBytes, labels},
+		{qbittorrentTorrentUploadSpeedBytes, helpQbittorrentTorrentUploadSpeedBytes, labels},
+		{qbittorrentTorrentSeeders, helpQbittorrentTorrentSeeders, labels},
+		{qbittorrentTorrentLeechers, helpQbittorrentTorrentLeechers, labels},
+		{qbittorrentTorrentAmountLeftBytes, helpQbittorrentTorrentAmountLeftBytes, labels},
+		{qbittorrentTorrentSizeBytes, helpQbittorrentTorrentSizeBytes, labels},
+	}
+
+	metrics := registerGauge(&gauges, r)
+
+	var dlspeed, upspeed, seeders, leechers, amountLeft, size int64
+
+	for _, torrent := range others {
+		dlspeed += torrent.Dlspeed
+		upspeed += torrent.Upspeed
+		seeders += torrent.NumSeeds
+		leechers += torrent.NumLeechs
+		amountLeft += torrent.AmountLeft
+		size += torrent.Size
+	}
+
+	otherLabels := map[string]string{labelBucket: otherTorrentsBucket}
+
+	metrics[qbittorrentTorrentDownloadSpeedBytes].With(otherLabels).Set(float64(dlspeed))
+	metrics[qbittorrentTorrentUploadSpeedBytes].With(otherLabels).Set(float64(upspeed))
+	metrics[qbittorrentTorrentSeeders].With(otherLabels).Set(float64(seeders))
+	metrics[qbittorrentTorrentLeechers].With(otherLabels).Set(float64(leechers))
+	metrics[qbittorrentTorrentAmountLeftBytes].With(otherLabels).Set(float64(amountLeft))
+	metrics[qbittorrentTorrentSizeBytes].With(otherLabels).Set(float64(size))
+
+	newGauge(r, qbittorrentTorrentOtherTorrents, helpQbittorrentTorrentOtherTorrents).Set(float64(len(others)))
+}
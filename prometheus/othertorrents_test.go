@@ -0,0 +1,66 @@
+package prom
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	API "qbit-exp/api"
+)
+
+func TestOtherTorrents(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry(nil)
+
+	others := API.SliceInfo{
+		{Name: "a", Upspeed: 10, Dlspeed: 1, NumSeeds: 2, NumLeechs: 1, AmountLeft: 0, Size: 100}, //nolint:exhaustruct
+		{Name: "b", Upspeed: 5, Dlspeed: 4, NumSeeds: 1, NumLeechs: 3, AmountLeft: 20, Size: 50},  //nolint:exhaustruct
+	}
+
+	OtherTorrents(others, registry)
+
+	var output bytes.Buffer
+
+	registry.WritePrometheus(&output)
+
+	for _, expected := range []string{
+		`qbittorrent_torrent_upload_speed_bytes{bucket="other"} 15`,
+		`qbittorrent_torrent_download_speed_bytes{bucket="other"} 5`,
+		`qbittorrent_torrent_seeders{bucket="other"} 3`,
+		`qbittorrent_torrent_leechers{bucket="other"} 4`,
+		`qbittorrent_torrent_amount_left_bytes{bucket="other"} 20`,
+		`qbittorrent_torrent_size_bytes{bucket="other"} 150`,
+		`qbittorrent_torrent_other_torrents 2`,
+	} {
+		if !strings.Contains(output.String(), expected+"\n") {
+			t.Errorf("expected %s in\n%s", expected, output.String())
+		}
+	}
+}
+
+func TestOtherTorrentsNamedOther(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry(nil)
+
+	torrents := API.SliceInfo{
+		{Name: "other", Hash: "a", Size: 10}, //nolint:exhaustruct
+	}
+
+	Torrent(&torrents, nil, new("2.11.0"), registry)
+	OtherTorrents(API.SliceInfo{{Name: "b", Size: 100}}, registry) //nolint:exhaustruct
+
+	var output bytes.Buffer
+
+	registry.WritePrometheus(&output)
+
+	for _, expected := range []string{
+		`qbittorrent_torrent_size_bytes{bucket="other"} 100`,
+		`qbittorrent_torrent_size_bytes{name="other"} 10`,
+	} {
+		if !strings.Contains(output.String(), expected+"\n") {
+			t.Errorf("expected %s in\n%s", expected, output.String())
+		}
+	}
+}
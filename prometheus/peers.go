@@ -0,0 +1,143 @@
+package prom
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	API "qbit-exp/api"
+)
+
+const (
+	peersLabelClient     string = "client"
+	peersLabelCountry    string = "country"
+	peersLabelConnection string = "connection"
+	peersLabelFlag       string = "flag"
+
+	// peersOther is the label value of the peers beyond the cardinality cap.
+	peersOther string = "other"
+)
+
+const (
+	qbittorrentTorrentPeers                   string = metricCatTorrent + "peers"
+	qbittorrentTorrentPeersDownloadSpeedBytes string = metricCatTorrent + "peers" + separator + torrentLabelDownloadSpeed
+	qbittorrentTorrentPeersUploadSpeedBytes   string = metricCatTorrent + "peers" + separator + torrentLabelUploadSpeed
+	qbittorrentTorrentPeersFlag               string = metricCatTorrent + "peers" + separator + peersLabelFlag
+)
+
+const (
+	helpQbittorrentTorrentPeers                   string = "The number of peers of torrents by client, country and connection type"
+	helpQbittorrentTorrentPeersDownloadSpeedBytes string = "The download speed from peers of torrents by client, country and connection type" + BytesHelper
+	helpQbittorrentTorrentPeersUploadSpeedBytes   string = "The upload speed to peers of torrents by client, country and connection type" + BytesHelper
+	helpQbittorrentTorrentPeersFlag               string = "The number of peers of torrents by flag"
+)
+
+// TorrentPeers are the peers of a torrent.
+type TorrentPeers struct {
+	Torrent API.Info
+	Peers   []API.Peer
+}
+
+type peersGroup struct {
+	client     string
+	country    string
+	connection string
+
+	count         float64
+	downloadSpeed float64
+	uploadSpeed   float64
+}
+
+// Peers registers the peers metrics of the torrents. Peers are grouped by
+// client, country and connection type, with at most maxGroups series per
+// torrent: the smallest groups are merged in an "other" series.
+func Peers(result []TorrentPeers, maxGroups int, r *Registry) {
+	labels := baseTorrentLabelNames()
+	labelsWithGroup := append(append([]string{}, labels...), peersLabelClient, peersLabelCountry, peersLabelConnection)
+	labelsWithFlag := append(append([]string{}, labels...), peersLabelFlag)
+
+	gauges := GaugeList{
+		{qbittorrentTorrentPeers, helpQbittorrentTorrentPeers, labelsWithGroup},
+		{qbittorrentTorrentPeersDownloadSpeedBytes, helpQbittorrentTorrentPeersDownloadSpeedBytes, labelsWithGroup},
+		{qbittorrentTorrentPeersUploadSpeedBytes, helpQbittorrentTorrentPeersUploadSpeedBytes, labelsWithGroup},
+		{qbittorrentTorrentPeersFlag, helpQbittorrentTorrentPeersFlag, labelsWithFlag},
+	}
+
+	metrics := registerGauge(&gauges, r)
+
+	for _, torrentPeers := range result {
+		for _, group := range groupPeers(torrentPeers.Peers, maxGroups) {
+			groupLabels := baseTorrentLabels(torrentPeers.Torrent)
+			groupLabels[peersLabelClient] = group.client
+			groupLabels[peersLabelCountry] = group.country
+			groupLabels[peersLabelConnection] = group.connection
+
+			metrics[qbittorrentTorrentPeers].With(groupLabels).Set(group.count)
+			metrics[qbittorrentTorrentPeersDownloadSpeedBytes].With(groupLabels).Set(group.downloadSpeed)
+			metrics[qbittorrentTorrentPeersUploadSpeedBytes].With(groupLabels).Set(group.uploadSpeed)
+		}
+
+		flags := make(map[string]float64)
+
+		for _, peer := range torrentPeers.Peers {
+			for flag := range strings.FieldsSeq(peer.Flags) {
+				flags[flag]++
+			}
+		}
+
+		for flag, count := range flags {
+			flagLabels := baseTorrentLabels(torrentPeers.Torrent)
+			flagLabels[peersLabelFlag] = flag
+
+			metrics[qbittorrentTorrentPeersFlag].With(flagLabels).Set(count)
+		}
+	}
+}
+
+// groupPeers groups peers by client, country and connection type. Groups
+// beyond maxGroups are merged, the largest groups being kept.
+func groupPeers(peers []API.Peer, maxGroups int) []peersGroup {
+	byKey := make(map[[3]string]*peersGroup)
+
+	for _, peer := range peers {
+		key := [3]string{peer.Client, peer.CountryCode, peer.Connection}
+
+		group, exists := byKey[key]
+		if !exists {
+			group = &peersGroup{client: key[0], country: key[1], connection: key[2]} //nolint:exhaustruct
+			byKey[key] = group
+		}
+
+		group.count++
+		group.downloadSpeed += float64(peer.DlSpeed)
+		group.uploadSpeed += float64(peer.UpSpeed)
+	}
+
+	groups := make([]peersGroup, 0, len(byKey))
+	for _, group := range byKey {
+		groups = append(groups, *group)
+	}
+
+	slices.SortFunc(groups, func(a, b peersGroup) int {
+		return cmp.Or(
+			cmp.Compare(b.count, a.count),
+			cmp.Compare(b.uploadSpeed, a.uploadSpeed),
+			strings.Compare(a.client, b.client),
+			strings.Compare(a.country, b.country),
+			strings.Compare(a.connection, b.connection),
+		)
+	})
+
+	if maxGroups < 1 || len(groups) <= maxGroups {
+		return groups
+	}
+
+	other := peersGroup{client: peersOther, country: peersOther, connection: peersOther} //nolint:exhaustruct
+	for _, group := range groups[maxGroups-1:] {
+		other.count += group.count
+		other.downloadSpeed += group.downloadSpeed
+		other.uploadSpeed += group.uploadSpeed
+	}
+
+	return append(groups[:maxGroups-1], other)
+}
@@ -0,0 +1,70 @@
+package prom
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	API "qbit-exp/api"
+)
+
+func TestGroupPeers(t *testing.T) {
+	t.Parallel()
+
+	peers := []API.Peer{
+		{Client: "qBittorrent/5.0.0", CountryCode: "fr", Connection: "BT", UpSpeed: 100},        //nolint:exhaustruct
+		{Client: "qBittorrent/5.0.0", CountryCode: "fr", Connection: "BT", UpSpeed: 200},        //nolint:exhaustruct
+		{Client: "Transmission 4.0", CountryCode: "de", Connection: "μTP", UpSpeed: 50},         //nolint:exhaustruct
+		{Client: "Deluge 2.1", CountryCode: "us", Connection: "BT", DlSpeed: 10},                //nolint:exhaustruct
+		{Client: "libtorrent 2.0", CountryCode: "jp", Connection: "BT", UpSpeed: 1, DlSpeed: 5}, //nolint:exhaustruct
+	}
+
+	groups := groupPeers(peers, 0)
+	if len(groups) != 4 {
+		t.Fatalf("expected 4 groups without cap, got %d", len(groups))
+	}
+
+	if groups[0].client != "qBittorrent/5.0.0" || groups[0].count != 2 || groups[0].uploadSpeed != 300 {
+		t.Errorf("expected the largest group first, got %+v", groups[0])
+	}
+
+	groups = groupPeers(peers, 2)
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups with cap, got %d", len(groups))
+	}
+
+	other := groups[1]
+	if other.client != peersOther || other.count != 3 || other.uploadSpeed != 51 || other.downloadSpeed != 15 {
+		t.Errorf("expected the smallest groups to be merged, got %+v", other)
+	}
+}
+
+func TestPeers(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry(nil)
+
+	Peers([]TorrentPeers{{
+		Torrent: API.Info{Name: "ubuntu.iso"}, //nolint:exhaustruct
+		Peers: []API.Peer{
+			{Client: "qBittorrent/5.0.0", CountryCode: "fr", Connection: "BT", Flags: "D E", UpSpeed: 100}, //nolint:exhaustruct
+			{Client: "qBittorrent/5.0.0", CountryCode: "fr", Connection: "BT", Flags: "U E", UpSpeed: 200}, //nolint:exhaustruct
+		},
+	}}, 10, registry)
+
+	var output bytes.Buffer
+
+	registry.WritePrometheus(&output)
+
+	for _, expected := range []string{
+		`qbittorrent_torrent_peers{client="qBittorrent/5.0.0",connection="BT",country="fr",name="ubuntu.iso"} 2`,
+		`qbittorrent_torrent_peers_upload_speed_bytes{client="qBittorrent/5.0.0",connection="BT",country="fr",name="ubuntu.iso"} 300`,
+		`qbittorrent_torrent_peers_download_speed_bytes{client="qBittorrent/5.0.0",connection="BT",country="fr",name="ubuntu.iso"} 0`,
+		`qbittorrent_torrent_peers_flag{flag="E",name="ubuntu.iso"} 2`,
+		`qbittorrent_torrent_peers_flag{flag="D",name="ubuntu.iso"} 1`,
+	} {
+		if !strings.Contains(output.String(), expected+"\n") {
+			t.Errorf("expected %s in\n%s", expected, output.String())
+		}
+	}
+}
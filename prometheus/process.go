@@ -0,0 +1,36 @@
+package prom
+
+import (
+	"io"
+	"runtime"
+	"strings"
+
+	"github.com/VictoriaMetrics/metrics"
+)
+
+const (
+	qbittorrentExporterBuildInfo     string = metricCatExporter + "build_info"
+	helpQbittorrentExporterBuildInfo string = "The version of the exporter, the Go version it was built with and the enabled features"
+
+	buildInfoLabelVersion   string = "version"
+	buildInfoLabelGoVersion string = "goversion"
+	buildInfoLabelFeatures  string = "features"
+)
+
+// BuildInfo registers the build information of the exporter.
+func BuildInfo(r *Registry, version string, features []string) {
+	labels := []string{buildInfoLabelVersion, buildInfoLabelGoVersion, buildInfoLabelFeatures}
+
+	newGaugeVec(r, qbittorrentExporterBuildInfo, helpQbittorrentExporterBuildInfo, labels).With(map[string]string{
+		buildInfoLabelVersion:   version,
+		buildInfoLabelGoVersion: runtime.Version(),
+		buildInfoLabelFeatures:  strings.Join(features, ","),
+	}).Set(1)
+}
+
+// WriteProcessMetrics writes the process and Go runtime metrics of the
+// exporter, such as its memory usage and number of goroutines. They are only
+// available in the Prometheus text format.
+func WriteProcessMetrics(w io.Writer) {
+	metrics.WriteProcessMetrics(w)
+}
@@ -0,0 +1,29 @@
+package prom
+
+import (
+	"bytes"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestBuildInfo(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry(nil)
+
+	BuildInfo(registry, "v1.2.3", []string{"Trackers", "Legacy gauges"})
+
+	var output bytes.Buffer
+
+	WritePrometheus(&output, registry)
+
+	expected := `qbittorrent_exporter_build_info{features="Trackers,Legacy gauges",goversion="` + runtime.Version() + `",version="v1.2.3"} 1`
+	if !strings.Contains(output.String(), expected+"\n") {
+		t.Errorf("expected %s in\n%s", expected, output.String())
+	}
+
+	if !strings.Contains(output.String(), "# TYPE qbittorrent_exporter_build_info gauge\n") {
+		t.Errorf("expected the build info metadata in\n%s", output.String())
+	}
+}
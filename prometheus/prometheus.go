@@ -37,12 +37,59 @@ type GaugeSetting struct {
 type GaugeSet []GaugeSetting
 
 type GaugeVec struct {
-	set  *metrics.Set
-	name string
+	registry *Registry
+	name     string
+}
+
+// CounterList and CounterSet describe values that only go up. They are named
+// after their legacy gauge, the counter name gets the counterSuffix.
+type CounterList []Gauge
+
+type CounterSet []GaugeSetting
+
+type CounterVec struct {
+	registry    *Registry
+	name        string
+	createdName string
 }
 
 func (g *GaugeVec) With(labels map[string]string) *metrics.Gauge {
-	return g.set.GetOrCreateGauge(metricWithLabels(g.name, labels), nil)
+	return getOrCreateGauge(g.registry, g.name, labels)
+}
+
+func (c *CounterVec) With(labels map[string]string) *metrics.Counter {
+	return getOrCreateCounter(c.registry, c.name, labels)
+}
+
+// WithCreated returns the counter and records the Unix timestamp at which it
+// was created, written as a `_created` sample in the OpenMetrics format.
+func (c *CounterVec) WithCreated(labels map[string]string, created int64) *metrics.Counter {
+	if created > 0 {
+		getOrCreateGauge(c.registry, c.createdName, labels).Set(float64(created))
+	}
+
+	return c.With(labels)
+}
+
+// getOrCreateGauge returns the gauge of a series of r. A series dropped by
+// the relabel config gets a gauge that is not exported.
+func getOrCreateGauge(r *Registry, name string, labels map[string]string) *metrics.Gauge {
+	series, keep := r.series(name, labels)
+	if !keep {
+		return new(metrics.Gauge)
+	}
+
+	return r.GetOrCreateGauge(series, nil)
+}
+
+// getOrCreateCounter is getOrCreateGauge for counters.
+func getOrCreateCounter(r *Registry, name string, labels map[string]string) *metrics.Counter {
+	series, keep := r.series(name, labels)
+	if !keep {
+		return new(metrics.Counter)
+	}
+
+	return r.GetOrCreateCounter(series)
 }
 
 func metricWithLabels(name string, labels map[string]string) string {
@@ -83,6 +130,11 @@ const metricPrefix string = "qbittorrent"
 
 const separator string = "_"
 
+const (
+	counterSuffix string = separator + "total"
+	createdSuffix string = separator + "created"
+)
+
 // Metric categories.
 const (
 	metricCatApp      string = metricPrefix + separator + metricNameApp + separator
@@ -328,6 +380,10 @@ const (
 
 	qbittorrentTorrentTransferConnectionStatus     string = metricCatTransfer + torrentLabelConnectionStatus
 	helpQbittorrentTorrentTransferConnectionStatus string = "Connection status (connected, firewalled or disconnected)"
+	qbittorrentTransferDownloadRateLimitBytes      string = metricCatTransfer + globalLabelDownloadRateLimit
+	helpQbittorrentTransferDownloadRateLimitBytes  string = "The download rate limit currently applied, alternative limits included (0 means unlimited)" + BytesHelper
+	qbittorrentTransferUploadRateLimitBytes        string = metricCatTransfer + globalLabelUploadRateLimit
+	helpQbittorrentTransferUploadRateLimitBytes    string = "The upload rate limit currently applied, alternative limits included (0 means unlimited)" + BytesHelper
 )
 
 // Trackers.
@@ -349,10 +405,10 @@ const (
 	helpqbittorrentTrackerInfo       string = "All info for trackers"
 )
 
-func Version(result *[]byte, r *metrics.Set) {
-	r.GetOrCreateGauge(metricWithLabels(qbittorrentAppVersion, map[string]string{
-		"version": string(*result),
-	}), nil).Set(1)
+func Version(result *[]byte, r *Registry) {
+	newGaugeVec(r, qbittorrentAppVersion, helpQbittorrentAppVersion, []string{torrentLabelVersion}).With(map[string]string{
+		torrentLabelVersion: string(*result),
+	}).Set(1)
 }
 
 func createTorrentInfoLabels(enableHighCardinality, enableLabelWithHash bool, enableLabelWithTags bool) []string {
@@ -389,7 +445,7 @@ func createTorrentLabels(torrent API.Info, enableHighCardinality, enableLabelWit
 		labelName:                torrent.Name,
 		torrentLabelCategory:     torrent.Category,
 		torrentLabelState:        torrent.State,
-		torrentLabelTracker:      torrent.Tracker,
+		torrentLabelTracker:      trackerURLLabel(torrent.Tracker),
 		torrentLabelComment:      torrent.Comment,
 		torrentLabelSavePath:     torrent.SavePath,
 		torrentLabelAddedOn:      strconv.Itoa(int(torrent.AddedOn)),
@@ -425,15 +481,11 @@ func createTorrentLabels(torrent API.Info, enableHighCardinality, enableLabelWit
 	return infoLabels
 }
 
-func Torrent(result *API.SliceInfo, webUIVersion *string, r *metrics.Set) {
-	labels := []string{labelName}
-	if app.Exporter.ExperimentalFeatures.EnableLabelWithHash {
-		labels = append(labels, torrentLabelHash)
-	}
-
-	if app.Exporter.ExperimentalFeatures.EnableLabelWithTracker {
-		labels = append(labels, torrentLabelTracker)
-	}
+// Torrent registers the metrics of the torrents. Only the torrents for which
+// exported returns true get per-torrent series, all of them when exported is
+// nil; the counts include every torrent.
+func Torrent(result *API.SliceInfo, exported func(API.Info) bool, webUIVersion *string, r *Registry) {
+	labels := baseTorrentLabelNames()
 
 	labelsWithTag := append(append([]string{}, labels...), torrentLabelTag)
 	labelsWithComment := append(append([]string{}, labels...), torrentLabelComment)
@@ -452,10 +504,6 @@ func Torrent(result *API.SliceInfo, webUIVersion *string, r *metrics.Set) {
 		{qbittorrentTorrentRatio, helpQbittorrentTorrentRatio, labels},
 		{qbittorrentTorrentAmountLeftBytes, helpQbittorrentTorrentAmountLeftBytes, labels},
 		{qbittorrentTorrentSizeBytes, helpQbittorrentTorrentSizeBytes, labels},
-		{qbittorrentTorrentSessionDownloadedBytes, helpQbittorrentTorrentSessionDownloadedBytes, labels},
-		{qbittorrentTorrentSessionUploadedBytes, helpQbittorrentTorrentSessionUploadedBytes, labels},
-		{qbittorrentTorrentTotalDownloadedBytes, helpQbittorrentTorrentTotalDownloadedBytes, labels},
-		{qbittorrentTorrentTotalUploadedBytes, helpQbittorrentTorrentTotalUploadedBytes, labels},
 		{qbittorrentTorrentTags, helpQbittorrentTorrentTags, labelsWithTag},
 		{qbittorrentTorrentStates, helpQbittorrentTorrentStates, []string{labelName}},
 		{qbittorrentTorrentComment, helpQbittorrentTorrentComment, labelsWithComment},
@@ -465,7 +513,23 @@ func Torrent(result *API.SliceInfo, webUIVersion *string, r *metrics.Set) {
 		{qbittorrentTorrentCompletionOn, helpQbittorrentTorrentCompletionOn, labels},
 	}
 
+	counters := CounterList{
+		{qbittorrentTorrentSessionDownloadedBytes, helpQbittorrentTorrentSessionDownloadedBytes, labels},
+		{qbittorrentTorrentSessionUploadedBytes, helpQbittorrentTorrentSessionUploadedBytes, labels},
+		{qbittorrentTorrentTotalDownloadedBytes, helpQbittorrentTorrentTotalDownloadedBytes, labels},
+		{qbittorrentTorrentTotalUploadedBytes, helpQbittorrentTorrentTotalUploadedBytes, labels},
+	}
+
 	metrics := registerGauge(&gauges, r)
+	counterMetrics, legacyGauges := registerCounter(&counters, r)
+
+	setCounter := func(name string, labels map[string]string, value int64, created int64) {
+		counterMetrics[name].WithCreated(labels, created).Set(counterValue(value))
+
+		if legacyGauge, exists := legacyGauges[name]; exists {
+			legacyGauge.With(labels).Set(float64(value))
+		}
+	}
 
 	enableLabelWithHash := app.Exporter.ExperimentalFeatures.EnableLabelWithHash
 	enableLabelWithTags := app.Exporter.ExperimentalFeatures.EnableLabelWithTags
@@ -481,7 +545,7 @@ func Torrent(result *API.SliceInfo, webUIVersion *string, r *metrics.Set) {
 		metrics[qbittorrentTorrentInfo] = newGaugeVec(r, qbittorrentTorrentInfo, helpQbittorrentTorrentInfo, torrentInfoLabels)
 	}
 
-	qbittorrentGlobalTorrents := r.GetOrCreateGauge(qbittorrentGlobalTorrents, nil)
+	qbittorrentGlobalTorrents := newGauge(r, qbittorrentGlobalTorrents, helpqbittorrentGlobalTorrents)
 
 	var countStates = make(map[string]float64, len(allStates)+2)
 	for _, state := range allStates {
@@ -498,22 +562,19 @@ func Torrent(result *API.SliceInfo, webUIVersion *string, r *metrics.Set) {
 
 	countTotal := 0.0
 
-	baseTorrentLabels := func(t API.Info) map[string]string {
-		l := map[string]string{
-			labelName: t.Name,
-		}
-		if app.Exporter.ExperimentalFeatures.EnableLabelWithHash {
-			l[torrentLabelHash] = t.Hash
+	for _, torrent := range *result {
+		if _, exists := countStates[torrent.State]; exists {
+			countStates[torrent.State]++
+		} else {
+			logger.Error("Unknown state: " + torrent.State)
 		}
 
-		if app.Exporter.ExperimentalFeatures.EnableLabelWithTracker {
-			l[torrentLabelTracker] = t.Tracker
-		}
+		countTotal++
 
-		return l
-	}
+		if exported != nil && !exported(torrent) {
+			continue
+		}
 
-	for _, torrent := range *result {
 		torrentLabels := baseTorrentLabels(torrent)
 
 		metrics[qbittorrentTorrentEta].With(torrentLabels).Set(float64(torrent.Eta))
@@ -527,10 +588,10 @@ func Torrent(result *API.SliceInfo, webUIVersion *string, r *metrics.Set) {
 		metrics[qbittorrentTorrentRatio].With(torrentLabels).Set(torrent.Ratio)
 		metrics[qbittorrentTorrentAmountLeftBytes].With(torrentLabels).Set(float64(torrent.AmountLeft))
 		metrics[qbittorrentTorrentSizeBytes].With(torrentLabels).Set(float64(torrent.Size))
-		metrics[qbittorrentTorrentSessionDownloadedBytes].With(torrentLabels).Set(float64(torrent.DownloadedSession))
-		metrics[qbittorrentTorrentSessionUploadedBytes].With(torrentLabels).Set(float64(torrent.UploadedSession))
-		metrics[qbittorrentTorrentTotalDownloadedBytes].With(torrentLabels).Set(float64(torrent.Downloaded))
-		metrics[qbittorrentTorrentTotalUploadedBytes].With(torrentLabels).Set(float64(torrent.Uploaded))
+		setCounter(qbittorrentTorrentSessionDownloadedBytes, torrentLabels, torrent.DownloadedSession, 0)
+		setCounter(qbittorrentTorrentSessionUploadedBytes, torrentLabels, torrent.UploadedSession, 0)
+		setCounter(qbittorrentTorrentTotalDownloadedBytes, torrentLabels, torrent.Downloaded, torrent.AddedOn)
+		setCounter(qbittorrentTorrentTotalUploadedBytes, torrentLabels, torrent.Uploaded, torrent.AddedOn)
 		metrics[qbittorrentTorrentCompletionOn].With(torrentLabels).Set(float64(torrent.CompletionOn))
 		metrics[qbittorrentTorrentAddedOn].With(torrentLabels).Set(float64(torrent.AddedOn))
 
@@ -557,14 +618,6 @@ func Torrent(result *API.SliceInfo, webUIVersion *string, r *metrics.Set) {
 			}
 		}
 
-		if _, exists := countStates[torrent.State]; exists {
-			countStates[torrent.State]++
-		} else {
-			logger.Error("Unknown state: " + torrent.State)
-		}
-
-		countTotal++
-
 		var infoLabels map[string]string
 		if app.Exporter.Features.EnableHighCardinality {
 			infoLabels = createTorrentLabels(torrent, true, enableLabelWithHash, enableLabelWithTags)
@@ -592,7 +645,38 @@ func Torrent(result *API.SliceInfo, webUIVersion *string, r *metrics.Set) {
 	qbittorrentGlobalTorrents.Set(countTotal)
 }
 
-func Preference(result *API.Preferences, r *metrics.Set) {
+// baseTorrentLabels returns the labels identifying a torrent in the
+// qbittorrent_torrent_* metrics.
+func baseTorrentLabels(t API.Info) map[string]string {
+	l := map[string]string{
+		labelName: t.Name,
+	}
+	if app.Exporter.ExperimentalFeatures.EnableLabelWithHash {
+		l[torrentLabelHash] = t.Hash
+	}
+
+	if app.Exporter.ExperimentalFeatures.EnableLabelWithTracker {
+		l[torrentLabelTracker] = trackerURLLabel(t.Tracker)
+	}
+
+	return l
+}
+
+// baseTorrentLabelNames returns the names of the labels set by baseTorrentLabels.
+func baseTorrentLabelNames() []string {
+	labels := []string{labelName}
+	if app.Exporter.ExperimentalFeatures.EnableLabelWithHash {
+		labels = append(labels, torrentLabelHash)
+	}
+
+	if app.Exporter.ExperimentalFeatures.EnableLabelWithTracker {
+		labels = append(labels, torrentLabelTracker)
+	}
+
+	return labels
+}
+
+func Preference(result *API.Preferences, r *Registry) {
 	gauges := GaugeSet{
 		{qbittorrentGlobalMaxActiveDownloads, helpqbittorrentGlobalMaxActiveDownloads, float64(result.MaxActiveDownloads)},
 		{qbittorrentGlobalMaxActiveUploads, helpqbittorrentGlobalMaxActiveUploads, float64(result.MaxActiveUploads)},
@@ -606,7 +690,7 @@ func Preference(result *API.Preferences, r *metrics.Set) {
 	registerGaugeGlobalAndSet(&gauges, r)
 }
 
-func Trackers(result []*API.Trackers, r *metrics.Set) {
+func Trackers(result []*API.Trackers, r *Registry) {
 	if len(result) == 0 {
 		logger.Trace("No tracker")
 
@@ -625,6 +709,7 @@ func Trackers(result []*API.Trackers, r *metrics.Set) {
 	}
 
 	metrics := registerGauge(&gauges, r)
+	metrics[qbittorrentTrackerState] = newGaugeVec(r, qbittorrentTrackerState, helpQbittorrentTrackerState, []string{trackerLabelURL, torrentLabelState})
 
 	if app.Exporter.Features.EnableHighCardinality {
 		metrics[qbittorrentTrackerInfo] = newGaugeVec(r, qbittorrentTrackerInfo, helpqbittorrentTrackerInfo,
@@ -633,7 +718,7 @@ func Trackers(result []*API.Trackers, r *metrics.Set) {
 
 	for _, listOfTracker := range result {
 		for _, tracker := range *listOfTracker {
-			if internal.IsValidURL(tracker.URL) {
+			if trackerURL, valid := trackerLabel(tracker.URL); valid {
 				tier, err := strconv.Atoi(string(tracker.Tier))
 				if err != nil {
 					logger.Trace(fmt.Sprintf("can't convert \"%s\" to int", tracker.Tier))
@@ -641,13 +726,14 @@ func Trackers(result []*API.Trackers, r *metrics.Set) {
 					tier = 0
 				}
 
-				labels := map[string]string{trackerLabelURL: tracker.URL}
+				labels := map[string]string{trackerLabelURL: trackerURL}
 				metrics[qbittorrentTrackerDownloaded].With(labels).Set(float64(tracker.NumDownloaded))
 				metrics[qbittorrentTrackerLeeches].With(labels).Set(float64(tracker.NumLeeches))
 				metrics[qbittorrentTrackerSeeders].With(labels).Set(float64(tracker.NumSeeds))
 				metrics[qbittorrentTrackerPeers].With(labels).Set(float64(tracker.NumPeers))
 				metrics[qbittorrentTrackerStatus].With(labels).Set(float64(tracker.Status))
 				metrics[qbittorrentTrackerTier].With(labels).Set(float64(tier))
+				setTrackerState(metrics[qbittorrentTrackerState], trackerURL, tracker.Status)
 
 				if app.Exporter.Features.EnableHighCardinality {
 					qbittorrentTrackerInfoLabels := map[string]string{
@@ -658,7 +744,7 @@ func Trackers(result []*API.Trackers, r *metrics.Set) {
 						labelSeeders:        strconv.Itoa(tracker.NumSeeds),
 						trackerLabelStatus:  strconv.Itoa((tracker.Status)),
 						trackerLabelTier:    strconv.Itoa(tier),
-						trackerLabelURL:     tracker.URL}
+						trackerLabelURL:     trackerURL}
 					metrics[qbittorrentTrackerInfo].With(qbittorrentTrackerInfoLabels).Set(1)
 				}
 			}
@@ -666,7 +752,17 @@ func Trackers(result []*API.Trackers, r *metrics.Set) {
 	}
 }
 
-func MainData(result *API.MainData, r *metrics.Set) {
+// Transfer registers the live rate limits from transfer/info.
+func Transfer(result *API.TransferInfo, r *Registry) {
+	gauges := GaugeSet{
+		{qbittorrentTransferDownloadRateLimitBytes, helpQbittorrentTransferDownloadRateLimitBytes, float64(result.DlRateLimit)},
+		{qbittorrentTransferUploadRateLimitBytes, helpQbittorrentTransferUploadRateLimitBytes, float64(result.UpRateLimit)},
+	}
+
+	registerGaugeGlobalAndSet(&gauges, r)
+}
+
+func MainData(result *API.MainData, r *Registry) {
 	var (
 		globalRatio float64
 		err         error
@@ -685,7 +781,7 @@ func MainData(result *API.MainData, r *metrics.Set) {
 	}
 
 	if err == nil {
-		qbittorrentGlobalRatio := r.GetOrCreateGauge(qbittorrentGlobalRatio, nil)
+		qbittorrentGlobalRatio := newGauge(r, qbittorrentGlobalRatio, helpqbittorrentGlobalRatio)
 		qbittorrentGlobalRatio.Set(globalRatio)
 	}
 
@@ -694,14 +790,18 @@ func MainData(result *API.MainData, r *metrics.Set) {
 		useAltSpeedLimits = 1.0
 	}
 
-	qbittorrentAppAltRateLimitsEnabled := r.GetOrCreateGauge(qbittorrentAppAltRateLimitsEnabled, nil)
+	qbittorrentAppAltRateLimitsEnabled := newGauge(r, qbittorrentAppAltRateLimitsEnabled, helpQbittorrentAppAltRateLimitsEnabled)
 	qbittorrentAppAltRateLimitsEnabled.Set(float64(useAltSpeedLimits))
 
-	gauges := GaugeSet{
+	counters := CounterSet{
 		{qbittorrentGlobalAlltimeDownloadedBytes, helpqbittorrentGlobalAlltimeDownloadedBytes, float64(result.ServerState.AlltimeDl)},
 		{qbittorrentGlobalAlltimeUploadedBytes, helpqbittorrentGlobalAlltimeUploadedBytes, float64(result.ServerState.AlltimeUl)},
 		{qbittorrentGlobalSessionDownloadedBytes, helpqbittorrentGlobalSessionDownloadedBytes, float64(result.ServerState.DlInfoData)},
 		{qbittorrentGlobalSessionUploadedBytes, helpqbittorrentGlobalSessionUploadedBytes, float64(result.ServerState.UpInfoData)},
+		{qbittorrentGlobalTotalWastedSessionBytes, helpqbittorrentGlobalWastedSession, float64(result.ServerState.TotalWastedSession)},
+	}
+
+	gauges := GaugeSet{
 		{qbittorrentGlobalDownloadSpeedBytes, helpqbittorrentGlobalDownloadSpeedBytes, float64(result.ServerState.DlInfoSpeed)},
 		{qbittorrentGlobalUploadSpeedBytes, helpqbittorrentGlobalUploadSpeedBytes, float64(result.ServerState.UpInfoSpeed)},
 		{qbittorrentGlobalDHTNodes, helpqbittorrentGlobalDHTNodes, float64(result.ServerState.DHTNodes)},
@@ -711,7 +811,6 @@ func MainData(result *API.MainData, r *metrics.Set) {
 		{qbittorrentGlobalTotalBuffersSizeBytes, helpqbittorrentGlobalTotalBuffersSize, float64(result.ServerState.TotalBuffersSize)},
 		{qbittorrentGlobalTotalQueuedSizeBytes, helpqbittorrentGlobalTotalQueuedSize, float64(result.ServerState.TotalQueuedSize)},
 		{qbittorrentGlobalTotalPeerConnections, helpqbittorrentGlobalTotalPeerConnections, float64(result.ServerState.TotalPeerConnections)},
-		{qbittorrentGlobalTotalWastedSessionBytes, helpqbittorrentGlobalWastedSession, float64(result.ServerState.TotalWastedSession)},
 	}
 
 	qbittorrentTransferConnectionStatus := newGaugeVec(r,
@@ -724,6 +823,7 @@ func MainData(result *API.MainData, r *metrics.Set) {
 	}).Set(1)
 
 	registerGaugeGlobalAndSet(&gauges, r)
+	registerCounterGlobalAndSet(&counters, r)
 
 	qbittorrentGlobalTags := newGaugeVec(r, qbittorrentGlobalTags, helpqbittorrentGlobalTags, []string{torrentLabelTag})
 
@@ -746,14 +846,55 @@ func MainData(result *API.MainData, r *metrics.Set) {
 	}
 }
 
-func registerGaugeGlobalAndSet(gauges *GaugeSet, r *metrics.Set) {
+func registerGaugeGlobalAndSet(gauges *GaugeSet, r *Registry) {
 	for _, gauge := range *gauges {
-		g := r.GetOrCreateGauge(gauge.Name, nil)
+		g := newGauge(r, gauge.Name, gauge.Help)
 		g.Set(gauge.Value)
 	}
 }
 
-func registerGauge(gauges *GaugeList, r *metrics.Set) map[string]*GaugeVec {
+// registerCounterGlobalAndSet sets the counters, and their legacy gauge when
+// app.Exporter.Features.EnableLegacyGauges is set.
+func registerCounterGlobalAndSet(counters *CounterSet, r *Registry) {
+	for _, counter := range *counters {
+		newCounter(r, counter.Name+counterSuffix, counter.Help).Set(counterValue(int64(counter.Value)))
+
+		if app.Exporter.Features.EnableLegacyGauges {
+			newGauge(r, counter.Name, counter.Help).Set(counter.Value)
+		}
+	}
+}
+
+// registerCounter returns the counters and, when
+// app.Exporter.Features.EnableLegacyGauges is set, their legacy gauges. Both
+// maps are keyed by the legacy gauge name.
+func registerCounter(counters *CounterList, r *Registry) (map[string]*CounterVec, map[string]*GaugeVec) {
+	metrics := make(map[string]*CounterVec, len(*counters))
+	legacyGauges := make(map[string]*GaugeVec)
+
+	for _, counter := range *counters {
+		metrics[counter.Name] = newCounterVec(r, counter.Name+counterSuffix, counter.Help, counter.Labels)
+
+		if app.Exporter.Features.EnableLegacyGauges {
+			legacyGauges[counter.Name] = newGaugeVec(r, counter.Name, counter.Help, counter.Labels)
+		}
+	}
+
+	return metrics, legacyGauges
+}
+
+// counterValue converts a value reported by qBittorrent to a counter value.
+// qBittorrent restarts reset the session values, Prometheus handles them as
+// counter resets.
+func counterValue(value int64) uint64 {
+	if value < 0 {
+		return 0
+	}
+
+	return uint64(value)
+}
+
+func registerGauge(gauges *GaugeList, r *Registry) map[string]*GaugeVec {
 	metrics := make(map[string]*GaugeVec, len(*gauges))
 	for _, gauge := range *gauges {
 		metrics[gauge.Name] = newGaugeVec(r, gauge.Name, gauge.Help, gauge.Labels)
@@ -762,9 +903,36 @@ func registerGauge(gauges *GaugeList, r *metrics.Set) map[string]*GaugeVec {
 	return metrics
 }
 
-func newGaugeVec(r *metrics.Set, name, _ string, _ []string) *GaugeVec {
+func newGauge(r *Registry, name, help string) *metrics.Gauge {
+	registerMetadata(name, help, typeGauge)
+
+	return getOrCreateGauge(r, name, nil)
+}
+
+func newGaugeVec(r *Registry, name, help string, _ []string) *GaugeVec {
+	registerMetadata(name, help, typeGauge)
+
 	return &GaugeVec{
-		set:  r,
-		name: name,
+		registry: r,
+		name:     name,
+	}
+}
+
+func newCounter(r *Registry, name, help string) *metrics.Counter {
+	registerMetadata(name, help, typeCounter)
+
+	return getOrCreateCounter(r, name, nil)
+}
+
+func newCounterVec(r *Registry, name, help string, _ []string) *CounterVec {
+	createdName := strings.TrimSuffix(name, counterSuffix) + createdSuffix
+
+	registerMetadata(name, help, typeCounter)
+	registerMetadata(createdName, "", typeCreated)
+
+	return &CounterVec{
+		registry:    r,
+		name:        name,
+		createdName: createdName,
 	}
 }
@@ -12,8 +12,6 @@ import (
 	API "qbit-exp/api"
 	app "qbit-exp/app"
 	"qbit-exp/logger"
-
-	"github.com/VictoriaMetrics/metrics"
 )
 
 var buff = &bytes.Buffer{}
@@ -74,7 +72,7 @@ func TestPreference(t *testing.T) {
 		AltUpLimit:         50001,
 	}
 
-	registry := metrics.NewSet()
+	registry := NewRegistry(nil)
 
 	Preference(mockPrefs, registry)
 
@@ -123,29 +121,29 @@ func createMockMainData(globalRatio string) *API.MainData {
 func runMainDataTest(t *testing.T, data *API.MainData) {
 	t.Helper()
 
-	registry := metrics.NewSet()
+	registry := NewRegistry(nil)
 	MainData(data, registry)
 
 	expectedMetrics := map[string]float64{
-		"qbittorrent_global_ratio":                      2.5,
-		"qbittorrent_global_categories":                 1.0,
-		"qbittorrent_global_tags":                       1.0,
-		"qbittorrent_app_alt_rate_limits_enabled":       1.0,
-		"qbittorrent_global_alltime_downloaded_bytes":   100000, //nolint:misspell
-		"qbittorrent_global_alltime_uploaded_bytes":     100001, //nolint:misspell
-		"qbittorrent_global_session_downloaded_bytes":   100002,
-		"qbittorrent_global_session_uploaded_bytes":     100003,
-		"qbittorrent_global_download_speed_bytes":       100004,
-		"qbittorrent_global_upload_speed_bytes":         100005,
-		"qbittorrent_global_dht_nodes":                  0.0,
-		"qbittorrent_global_average_time_queue":         0.0,
-		"qbittorrent_global_free_space_on_disk_bytes":   0.0,
-		"qbittorrent_global_queued_io_jobs":             0.0,
-		"qbittorrent_global_total_buffers_size_bytes":   0.0,
-		"qbittorrent_global_total_peer_connections":     0.0,
-		"qbittorrent_global_total_queued_size_bytes":    0.0,
-		"qbittorrent_global_total_wasted_session_bytes": 0.0,
-		"qbittorrent_transfer_connection_status":        1.0,
+		"qbittorrent_global_ratio":                            2.5,
+		"qbittorrent_global_categories":                       1.0,
+		"qbittorrent_global_tags":                             1.0,
+		"qbittorrent_app_alt_rate_limits_enabled":             1.0,
+		"qbittorrent_global_alltime_downloaded_bytes_total":   100000, //nolint:misspell
+		"qbittorrent_global_alltime_uploaded_bytes_total":     100001, //nolint:misspell
+		"qbittorrent_global_session_downloaded_bytes_total":   100002,
+		"qbittorrent_global_session_uploaded_bytes_total":     100003,
+		"qbittorrent_global_download_speed_bytes":             100004,
+		"qbittorrent_global_upload_speed_bytes":               100005,
+		"qbittorrent_global_dht_nodes":                        0.0,
+		"qbittorrent_global_average_time_queue":               0.0,
+		"qbittorrent_global_free_space_on_disk_bytes":         0.0,
+		"qbittorrent_global_queued_io_jobs":                   0.0,
+		"qbittorrent_global_total_buffers_size_bytes":         0.0,
+		"qbittorrent_global_total_peer_connections":           0.0,
+		"qbittorrent_global_total_queued_size_bytes":          0.0,
+		"qbittorrent_global_total_wasted_session_bytes_total": 0.0,
+		"qbittorrent_transfer_connection_status":              1.0,
 	}
 	testMetrics(t, expectedMetrics, registry)
 
@@ -172,7 +170,7 @@ func TestVersion(t *testing.T) {
 	expectedVersion := "v5.0.2"
 	version := []byte(expectedVersion)
 
-	registry := metrics.NewSet()
+	registry := NewRegistry(nil)
 	Version(&version, registry)
 
 	families, labels := parseSetMetrics(t, registry)
@@ -235,38 +233,69 @@ func TestTorrent(t *testing.T) {
 		},
 	}
 
-	registry := metrics.NewSet()
+	registry := NewRegistry(nil)
 
 	webuiversion := "2.11.2"
 
-	Torrent(mockInfo, &webuiversion, registry)
+	Torrent(mockInfo, nil, &webuiversion, registry)
 
 	expectedMetrics := map[string]float64{
-		"qbittorrent_torrent_eta":                      120,
-		"qbittorrent_torrent_download_speed_bytes":     500000,
-		"qbittorrent_torrent_upload_speed_bytes":       250000,
-		"qbittorrent_torrent_progress":                 0.0315,
-		"qbittorrent_torrent_popularity":               3.25,
-		"qbittorrent_torrent_time_active":              3600,
-		"qbittorrent_torrent_seeders":                  10,
-		"qbittorrent_torrent_leechers":                 5,
-		"qbittorrent_torrent_ratio":                    1.5,
-		"qbittorrent_torrent_amount_left_bytes":        1000000000,
-		"qbittorrent_torrent_size_bytes":               5000000000,
-		"qbittorrent_torrent_session_downloaded_bytes": 250000000,
-		"qbittorrent_torrent_session_uploaded_bytes":   100000000,
-		"qbittorrent_torrent_total_downloaded_bytes":   1000000000,
-		"qbittorrent_torrent_total_uploaded_bytes":     500000000,
-		"qbittorrent_global_torrents":                  1,
-		"qbittorrent_torrent_added_on":                 1664715487,
-		"qbittorrent_torrent_completed_on":             1664719487,
-		"qbittorrent_torrent_states":                   0,
-		"qbittorrent_torrent_tags":                     1,
+		"qbittorrent_torrent_eta":                            120,
+		"qbittorrent_torrent_download_speed_bytes":           500000,
+		"qbittorrent_torrent_upload_speed_bytes":             250000,
+		"qbittorrent_torrent_progress":                       0.0315,
+		"qbittorrent_torrent_popularity":                     3.25,
+		"qbittorrent_torrent_time_active":                    3600,
+		"qbittorrent_torrent_seeders":                        10,
+		"qbittorrent_torrent_leechers":                       5,
+		"qbittorrent_torrent_ratio":                          1.5,
+		"qbittorrent_torrent_amount_left_bytes":              1000000000,
+		"qbittorrent_torrent_size_bytes":                     5000000000,
+		"qbittorrent_torrent_session_downloaded_bytes_total": 250000000,
+		"qbittorrent_torrent_session_uploaded_bytes_total":   100000000,
+		"qbittorrent_torrent_total_downloaded_bytes_total":   1000000000,
+		"qbittorrent_torrent_total_uploaded_bytes_total":     500000000,
+		"qbittorrent_global_torrents":                        1,
+		"qbittorrent_torrent_added_on":                       1664715487,
+		"qbittorrent_torrent_completed_on":                   1664719487,
+		"qbittorrent_torrent_states":                         0,
+		"qbittorrent_torrent_tags":                           1,
 	}
 
 	testMetrics(t, expectedMetrics, registry)
 }
 
+func TestTorrentExported(t *testing.T) {
+	t.Parallel()
+
+	torrents := &API.SliceInfo{
+		{Hash: "a", Name: "exported", State: "uploading"},     //nolint:exhaustruct
+		{Hash: "b", Name: "not-exported", State: "uploading"}, //nolint:exhaustruct
+	}
+
+	registry := NewRegistry(nil)
+
+	Torrent(torrents, func(torrent API.Info) bool { return torrent.Hash == "a" }, new("2.11.2"), registry)
+
+	var output bytes.Buffer
+
+	registry.WritePrometheus(&output)
+
+	for _, expected := range []string{
+		`qbittorrent_torrent_size_bytes{name="exported"} 0`,
+		`qbittorrent_torrent_states{name="uploading"} 2`,
+		`qbittorrent_global_torrents 2`,
+	} {
+		if !strings.Contains(output.String(), expected+"\n") {
+			t.Errorf("expected %s in\n%s", expected, output.String())
+		}
+	}
+
+	if strings.Contains(output.String(), "not-exported") {
+		t.Errorf("expected no series for the filtered torrent, got\n%s", output.String())
+	}
+}
+
 func TestTrackers(t *testing.T) {
 	t.Parallel()
 
@@ -285,7 +314,7 @@ func TestTrackers(t *testing.T) {
 		},
 	}
 
-	registry := metrics.NewSet()
+	registry := NewRegistry(nil)
 	Trackers(mockTrackers, registry)
 
 	expectedMetrics := map[string]float64{
@@ -300,7 +329,7 @@ func TestTrackers(t *testing.T) {
 	testMetrics(t, expectedMetrics, registry)
 }
 
-func testMetrics(t *testing.T, expectedMetrics map[string]float64, registry *metrics.Set) {
+func testMetrics(t *testing.T, expectedMetrics map[string]float64, registry *Registry) {
 	t.Helper()
 	metricFamilies, _ := parseSetMetrics(t, registry)
 
@@ -319,7 +348,7 @@ func testMetrics(t *testing.T, expectedMetrics map[string]float64, registry *met
 	}
 }
 
-func testMultipleMetrics(t *testing.T, multipleMetrics map[string][]string, registry *metrics.Set) {
+func testMultipleMetrics(t *testing.T, multipleMetrics map[string][]string, registry *Registry) {
 	t.Helper()
 	_, metricLabels := parseSetMetrics(t, registry)
 
@@ -344,7 +373,7 @@ func testMultipleMetrics(t *testing.T, multipleMetrics map[string][]string, regi
 	}
 }
 
-func parseSetMetrics(t *testing.T, set *metrics.Set) (map[string][]float64, map[string][]map[string]string) {
+func parseSetMetrics(t *testing.T, set *Registry) (map[string][]float64, map[string][]map[string]string) {
 	t.Helper()
 
 	var output bytes.Buffer
@@ -662,3 +691,47 @@ func TestCreateTorrentLabels(t *testing.T) {
 		})
 	}
 }
+
+func TestLegacyGauges(t *testing.T) { //nolint:paralleltest
+	app.Exporter.Features.EnableLegacyGauges = true
+
+	defer func() {
+		app.Exporter.Features.EnableLegacyGauges = false
+	}()
+
+	registry := NewRegistry(nil)
+	MainData(createMockMainData("2.5"), registry)
+
+	expectedMetrics := map[string]float64{
+		"qbittorrent_global_alltime_downloaded_bytes":       100000, //nolint:misspell
+		"qbittorrent_global_alltime_downloaded_bytes_total": 100000, //nolint:misspell
+		"qbittorrent_global_session_uploaded_bytes":         100003,
+		"qbittorrent_global_session_uploaded_bytes_total":   100003,
+	}
+	testMetrics(t, expectedMetrics, registry)
+
+	var output bytes.Buffer
+
+	WritePrometheus(&output, registry)
+
+	for _, expected := range []string{
+		"# TYPE qbittorrent_global_alltime_downloaded_bytes gauge\n",         //nolint:misspell
+		"# TYPE qbittorrent_global_alltime_downloaded_bytes_total counter\n", //nolint:misspell
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("expected %q in\n%s", expected, output.String())
+		}
+	}
+}
+
+func TestCounterValue(t *testing.T) {
+	t.Parallel()
+
+	if got := counterValue(-1); got != 0 {
+		t.Errorf("expected negative values to be clamped to 0, got %d", got)
+	}
+
+	if got := counterValue(42); got != 42 {
+		t.Errorf("expected 42, got %d", got)
+	}
+}
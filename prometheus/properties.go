@@ -0,0 +1,87 @@
+package prom
+
+import (
+	API "qbit-exp/api"
+)
+
+const (
+	qbittorrentTorrentPieceSizeBytes   string = metricCatTorrent + "piece_size" + separator + torrentLabelBytes
+	qbittorrentTorrentPieces           string = metricCatTorrent + "pieces"
+	qbittorrentTorrentPiecesHave       string = metricCatTorrent + "pieces_have"
+	qbittorrentTorrentWastedBytes      string = metricCatTorrent + "wasted" + separator + torrentLabelBytes
+	qbittorrentTorrentConnections      string = metricCatTorrent + "connections"
+	qbittorrentTorrentConnectionsLimit string = metricCatTorrent + "connections_limit"
+	qbittorrentTorrentRatioLimit       string = metricCatTorrent + "ratio_limit"
+	qbittorrentTorrentSeedingTime      string = metricCatTorrent + "seeding_time"
+	qbittorrentTorrentSeedingTimeLimit string = metricCatTorrent + "seeding_time_limit"
+	qbittorrentTorrentLastSeenComplete string = metricCatTorrent + "last_seen_complete"
+	qbittorrentTorrentReannounce       string = metricCatTorrent + "reannounce"
+	qbittorrentTorrentCreationDate     string = metricCatTorrent + "creation_date"
+)
+
+const (
+	helpQbittorrentTorrentPieceSizeBytes   string = "The piece size of torrents" + BytesHelper
+	helpQbittorrentTorrentPieces           string = "The number of pieces of torrents"
+	helpQbittorrentTorrentPiecesHave       string = "The number of pieces downloaded"
+	helpQbittorrentTorrentWastedBytes      string = "The data wasted by torrents" + BytesHelper
+	helpQbittorrentTorrentConnections      string = "The number of connections of torrents"
+	helpQbittorrentTorrentConnectionsLimit string = "The max number of connections of torrents"
+	helpQbittorrentTorrentRatioLimit       string = "The ratio limit of torrents (-2 means the global limit, -1 means no limit)"
+	helpQbittorrentTorrentSeedingTime      string = "The total seeding time" + SecondsHelper
+	helpQbittorrentTorrentSeedingTimeLimit string = "The seeding time limit of torrents, in minutes (-2 means the global limit, -1 means no limit)"
+	helpQbittorrentTorrentLastSeenComplete string = "Timestamp when this torrent was last seen complete"
+	helpQbittorrentTorrentReannounce       string = "The time until the next announce" + SecondsHelper
+	helpQbittorrentTorrentCreationDate     string = "Timestamp when this torrent was created"
+)
+
+// TorrentProperties are the properties of a torrent.
+type TorrentProperties struct {
+	Torrent    API.Info
+	Properties API.Properties
+}
+
+// Properties registers the metrics from torrents/properties.
+func Properties(result []TorrentProperties, r *Registry) {
+	labels := baseTorrentLabelNames()
+
+	gauges := GaugeList{
+		{qbittorrentTorrentPieceSizeBytes, helpQbittorrentTorrentPieceSizeBytes, labels},
+		{qbittorrentTorrentPieces, helpQbittorrentTorrentPieces, labels},
+		{qbittorrentTorrentPiecesHave, helpQbittorrentTorrentPiecesHave, labels},
+		{qbittorrentTorrentWastedBytes, helpQbittorrentTorrentWastedBytes, labels},
+		{qbittorrentTorrentConnections, helpQbittorrentTorrentConnections, labels},
+		{qbittorrentTorrentConnectionsLimit, helpQbittorrentTorrentConnectionsLimit, labels},
+		{qbittorrentTorrentRatioLimit, helpQbittorrentTorrentRatioLimit, labels},
+		{qbittorrentTorrentSeedingTime, helpQbittorrentTorrentSeedingTime, labels},
+		{qbittorrentTorrentSeedingTimeLimit, helpQbittorrentTorrentSeedingTimeLimit, labels},
+		{qbittorrentTorrentLastSeenComplete, helpQbittorrentTorrentLastSeenComplete, labels},
+		{qbittorrentTorrentReannounce, helpQbittorrentTorrentReannounce, labels},
+		{qbittorrentTorrentCreationDate, helpQbittorrentTorrentCreationDate, labels},
+	}
+
+	metrics := registerGauge(&gauges, r)
+
+	for _, torrentProperties := range result {
+		torrentLabels := baseTorrentLabels(torrentProperties.Torrent)
+		properties := torrentProperties.Properties
+
+		metrics[qbittorrentTorrentPieceSizeBytes].With(torrentLabels).Set(float64(properties.PieceSize))
+		metrics[qbittorrentTorrentPieces].With(torrentLabels).Set(float64(properties.PiecesNum))
+		metrics[qbittorrentTorrentPiecesHave].With(torrentLabels).Set(float64(properties.PiecesHave))
+		metrics[qbittorrentTorrentWastedBytes].With(torrentLabels).Set(float64(properties.TotalWasted))
+		metrics[qbittorrentTorrentConnections].With(torrentLabels).Set(float64(properties.NbConnections))
+		metrics[qbittorrentTorrentConnectionsLimit].With(torrentLabels).Set(float64(properties.NbConnectionsLimit))
+		metrics[qbittorrentTorrentSeedingTime].With(torrentLabels).Set(float64(properties.SeedingTime))
+		metrics[qbittorrentTorrentLastSeenComplete].With(torrentLabels).Set(float64(properties.LastSeen))
+		metrics[qbittorrentTorrentReannounce].With(torrentLabels).Set(float64(properties.Reannounce))
+		metrics[qbittorrentTorrentCreationDate].With(torrentLabels).Set(float64(properties.CreationDate))
+
+		if properties.RatioLimit != nil {
+			metrics[qbittorrentTorrentRatioLimit].With(torrentLabels).Set(*properties.RatioLimit)
+		}
+
+		if properties.SeedingTimeLimit != nil {
+			metrics[qbittorrentTorrentSeedingTimeLimit].With(torrentLabels).Set(float64(*properties.SeedingTimeLimit))
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package prom
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	API "qbit-exp/api"
+)
+
+func TestProperties(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry(nil)
+	ratioLimit := 2.5
+
+	Properties([]TorrentProperties{
+		{
+			Torrent: API.Info{Name: "with-limits"}, //nolint:exhaustruct
+			Properties: API.Properties{ //nolint:exhaustruct
+				PieceSize:  16384,
+				PiecesHave: 5,
+				PiecesNum:  8,
+				RatioLimit: &ratioLimit,
+			},
+		},
+		{
+			Torrent:    API.Info{Name: "without-limits"},  //nolint:exhaustruct
+			Properties: API.Properties{SeedingTime: 3600}, //nolint:exhaustruct
+		},
+	}, registry)
+
+	var output bytes.Buffer
+
+	registry.WritePrometheus(&output)
+
+	for _, expected := range []string{
+		`qbittorrent_torrent_piece_size_bytes{name="with-limits"} 16384`,
+		`qbittorrent_torrent_pieces_have{name="with-limits"} 5`,
+		`qbittorrent_torrent_pieces{name="with-limits"} 8`,
+		`qbittorrent_torrent_ratio_limit{name="with-limits"} 2.5`,
+		`qbittorrent_torrent_seeding_time{name="without-limits"} 3600`,
+	} {
+		if !strings.Contains(output.String(), expected+"\n") {
+			t.Errorf("expected %s in\n%s", expected, output.String())
+		}
+	}
+
+	if strings.Contains(output.String(), `qbittorrent_torrent_ratio_limit{name="without-limits"}`) {
+		t.Errorf("expected no ratio limit when not sent, got\n%s", output.String())
+	}
+}
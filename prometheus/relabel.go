@@ -0,0 +1,212 @@
+package prom
+
+import (
+	"maps"
+	"regexp"
+	"strings"
+	"sync"
+
+	API "qbit-exp/api"
+	"qbit-exp/app"
+)
+
+type torrentSelector struct {
+	category *regexp.Regexp
+	tag      *regexp.Regexp
+}
+
+type labelReplacement struct {
+	label       string
+	regex       *regexp.Regexp
+	replacement string
+}
+
+// relabeler is the compiled form of an app.RelabelConfig.
+type relabeler struct {
+	dropMetrics  []*regexp.Regexp
+	replace      []labelReplacement
+	dropLabels   map[string]struct{}
+	renameLabels map[string]string
+
+	keepTorrents []torrentSelector
+	dropTorrents []torrentSelector
+
+	// torrents returns the torrents of the collection. They are only read
+	// by the first per-torrent series, once the torrents are synced.
+	torrents    func() API.SliceInfo
+	droppedOnce sync.Once
+	// droppedHashes and droppedNames tell whether the series of a torrent are
+	// dropped. A name is only dropped when every torrent with this name is.
+	droppedHashes map[string]bool
+	droppedNames  map[string]bool
+}
+
+func newRelabeler(config *app.RelabelConfig, torrents func() API.SliceInfo) *relabeler {
+	r := &relabeler{
+		dropMetrics:   make([]*regexp.Regexp, 0, len(config.DropMetrics)),
+		replace:       make([]labelReplacement, 0, len(config.Replace)),
+		dropLabels:    make(map[string]struct{}, len(config.DropLabels)),
+		renameLabels:  config.RenameLabels,
+		keepTorrents:  compileSelectors(config.KeepTorrents),
+		dropTorrents:  compileSelectors(config.DropTorrents),
+		torrents:      torrents,
+		droppedOnce:   sync.Once{},
+		droppedHashes: nil,
+		droppedNames:  nil,
+	}
+
+	for _, expr := range config.DropMetrics {
+		r.dropMetrics = append(r.dropMetrics, anchoredRegex(expr))
+	}
+
+	for _, replacement := range config.Replace {
+		r.replace = append(r.replace, labelReplacement{
+			label:       replacement.Label,
+			regex:       anchoredRegex(replacement.Regex),
+			replacement: replacement.Replacement,
+		})
+	}
+
+	for _, label := range config.DropLabels {
+		r.dropLabels[label] = struct{}{}
+	}
+
+	return r
+}
+
+// loadDroppedTorrents tells which torrents are dropped.
+func (r *relabeler) loadDroppedTorrents() {
+	torrents := r.torrents()
+
+	r.droppedHashes = make(map[string]bool, len(torrents))
+	r.droppedNames = make(map[string]bool, len(torrents))
+
+	for _, torrent := range torrents {
+		dropped := (len(r.keepTorrents) > 0 && !matchesAny(r.keepTorrents, torrent)) || matchesAny(r.dropTorrents, torrent)
+
+		r.droppedHashes[torrent.Hash] = dropped
+
+		if alreadyDropped, exists := r.droppedNames[torrent.Name]; !exists || alreadyDropped {
+			r.droppedNames[torrent.Name] = dropped
+		}
+	}
+}
+
+// relabel returns the rewritten labels of a series, and false when it is
+// dropped. labels are not modified.
+func (r *relabeler) relabel(family string, labels map[string]string) (map[string]string, bool) {
+	for _, regex := range r.dropMetrics {
+		if regex.MatchString(family) {
+			return nil, false
+		}
+	}
+
+	if r.isTorrentDropped(family, labels) {
+		return nil, false
+	}
+
+	labels = maps.Clone(labels)
+
+	for _, replacement := range r.replace {
+		if value, exists := labels[replacement.label]; exists && replacement.regex.MatchString(value) {
+			labels[replacement.label] = replacement.regex.ReplaceAllString(value, replacement.replacement)
+		}
+	}
+
+	for label := range r.dropLabels {
+		delete(labels, label)
+	}
+
+	renamed := make(map[string]string, len(labels))
+
+	for label, value := range labels {
+		if to, exists := r.renameLabels[label]; exists {
+			label = to
+		}
+
+		renamed[label] = value
+	}
+
+	return renamed, true
+}
+
+// isTorrentDropped tells whether a series belongs to a dropped torrent. The
+// torrent states count uses the name label for the state, it is never
+// dropped.
+func (r *relabeler) isTorrentDropped(family string, labels map[string]string) bool {
+	if !strings.HasPrefix(family, metricCatTorrent) || family == qbittorrentTorrentStates {
+		return false
+	}
+
+	r.droppedOnce.Do(r.loadDroppedTorrents)
+
+	if hash, exists := labels[torrentLabelHash]; exists {
+		return r.droppedHashes[hash]
+	}
+
+	if name, exists := labels[labelName]; exists {
+		return r.droppedNames[name]
+	}
+
+	return false
+}
+
+func compileSelectors(selectors []app.TorrentSelector) []torrentSelector {
+	compiled := make([]torrentSelector, 0, len(selectors))
+
+	for _, selector := range selectors {
+		compiled = append(compiled, torrentSelector{
+			category: optionalRegex(selector.Category),
+			tag:      optionalRegex(selector.Tag),
+		})
+	}
+
+	return compiled
+}
+
+// matchesAny tells whether a torrent matches one of the selectors. An empty
+// expression matches every torrent.
+func matchesAny(selectors []torrentSelector, torrent API.Info) bool {
+	for _, selector := range selectors {
+		if selector.category != nil && !selector.category.MatchString(torrent.Category) {
+			continue
+		}
+
+		if selector.tag != nil && !matchesTag(selector.tag, torrent.Tags) {
+			continue
+		}
+
+		return true
+	}
+
+	return false
+}
+
+func matchesTag(regex *regexp.Regexp, tags string) bool {
+	if tags == "" {
+		return false
+	}
+
+	for tag := range strings.SplitSeq(tags, ", ") {
+		if regex.MatchString(tag) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// anchoredRegex compiles a fully anchored regular expression, validated by
+// the app package.
+func anchoredRegex(expr string) *regexp.Regexp {
+	return regexp.MustCompile("^(?:" + expr + ")$")
+}
+
+// optionalRegex is anchoredRegex, nil for an empty expression.
+func optionalRegex(expr string) *regexp.Regexp {
+	if expr == "" {
+		return nil
+	}
+
+	return anchoredRegex(expr)
+}
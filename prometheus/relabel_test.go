@@ -0,0 +1,98 @@
+package prom
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	API "qbit-exp/api"
+	"qbit-exp/app"
+)
+
+func TestRelabel(t *testing.T) {
+	t.Parallel()
+
+	torrents := API.SliceInfo{
+		{Hash: "a", Name: "Movie.mkv", Category: "movies", Size: 10},             //nolint:exhaustruct
+		{Hash: "b", Name: "Show.mkv", Category: "tv", Tags: "private", Size: 20}, //nolint:exhaustruct
+		{Hash: "c", Name: "Linux.iso", Category: "software", Size: 30},           //nolint:exhaustruct
+		{Hash: "d", Name: "Other.mkv", Category: "software", Size: 40},           //nolint:exhaustruct
+	}
+
+	config := &app.RelabelConfig{
+		DropMetrics:  []string{"qbittorrent_torrent_(eta|popularity)"},
+		KeepTorrents: []app.TorrentSelector{{Category: "movies|tv", Tag: ""}},
+		DropTorrents: []app.TorrentSelector{{Category: "", Tag: "priv.*"}},
+		Replace:      []app.LabelReplacement{{Label: "name", Regex: `(.*)\.mkv`, Replacement: "$1"}},
+		DropLabels:   []string{"connection_status"},
+		RenameLabels: map[string]string{"name": "torrent"},
+	}
+
+	set := NewRelabeledRegistry(nil, config, func() API.SliceInfo { return torrents })
+
+	Torrent(&torrents, nil, new("2.11.0"), set)
+	MainData(&API.MainData{}, set) //nolint:exhaustruct
+
+	var output bytes.Buffer
+
+	set.WritePrometheus(&output)
+
+	for _, expected := range []string{
+		`qbittorrent_torrent_size_bytes{torrent="Movie"} 10`,
+		`qbittorrent_torrent_states{torrent="downloading"} 0`,
+		`qbittorrent_transfer_connection_status 1`,
+		`qbittorrent_torrent_total_downloaded_bytes_total{torrent="Movie"} 0`,
+	} {
+		if !strings.Contains(output.String(), expected+"\n") {
+			t.Errorf("expected %s in\n%s", expected, output.String())
+		}
+	}
+
+	for _, unexpected := range []string{
+		"qbittorrent_torrent_eta",
+		"qbittorrent_torrent_popularity",
+		`torrent="Show"`,
+		`torrent="Linux.iso"`,
+		`torrent="Other"`,
+		`name=`,
+	} {
+		if strings.Contains(output.String(), unexpected) {
+			t.Errorf("expected no %s in\n%s", unexpected, output.String())
+		}
+	}
+}
+
+func TestRelabelCollision(t *testing.T) {
+	t.Parallel()
+
+	config := &app.RelabelConfig{
+		DropMetrics:  nil,
+		KeepTorrents: nil,
+		DropTorrents: nil,
+		Replace:      nil,
+		DropLabels:   []string{"hash"},
+		RenameLabels: nil,
+	}
+
+	set := NewRelabeledRegistry(map[string]string{LabelInstance: "box1"}, config, func() API.SliceInfo { return nil })
+
+	ratio := newGaugeVec(set, qbittorrentTorrentRatio, helpQbittorrentTorrentRatio, nil)
+	ratio.With(map[string]string{labelName: "a", torrentLabelHash: "1"}).Set(1)
+	ratio.With(map[string]string{labelName: "a", torrentLabelHash: "2"}).Set(2)
+	ratio.With(map[string]string{labelName: "a", torrentLabelHash: "1"}).Set(3)
+	ratio.With(map[string]string{labelName: "b", torrentLabelHash: "3"}).Set(4)
+	ratio.With(map[string]string{labelName: "b", torrentLabelHash: "3"}).Set(5)
+
+	var output bytes.Buffer
+
+	set.WritePrometheus(&output)
+
+	if strings.Contains(output.String(), `name="a"`) {
+		t.Errorf("expected the colliding series to be dropped in\n%s", output.String())
+	}
+
+	expected := `qbittorrent_torrent_ratio{name="b",qbittorrent_instance="box1"} 5`
+	if !strings.Contains(output.String(), expected+"\n") {
+		t.Errorf("expected %s in\n%s", expected, output.String())
+	}
+}
@@ -0,0 +1,101 @@
+package prom
+
+import (
+	"time"
+
+	API "qbit-exp/api"
+)
+
+const (
+	metricNameRSS string = "rss"
+	metricCatRSS  string = metricPrefix + separator + metricNameRSS + separator
+
+	rssLabelFeed string = "feed"
+
+	qbittorrentRSSFeedArticles       string = metricCatRSS + "feed_articles"
+	qbittorrentRSSFeedUnreadArticles string = metricCatRSS + "feed_unread_articles"
+	qbittorrentRSSFeedLastBuildDate  string = metricCatRSS + "feed_last_build_date"
+	qbittorrentRSSFeedError          string = metricCatRSS + "feed_error"
+	qbittorrentRSSRules              string = metricCatRSS + "rules"
+	qbittorrentRSSRulesEnabled       string = metricCatRSS + "rules_enabled"
+
+	helpQbittorrentRSSFeedArticles       string = "The number of articles of RSS feeds"
+	helpQbittorrentRSSFeedUnreadArticles string = "The number of unread articles of RSS feeds"
+	helpQbittorrentRSSFeedLastBuildDate  string = "Timestamp when RSS feeds were last updated, as given by the feeds"
+	helpQbittorrentRSSFeedError          string = "Whether the last refresh of RSS feeds failed (1 = failed, 0 = succeeded)"
+	helpQbittorrentRSSRules              string = "The number of RSS auto-downloading rules"
+	helpQbittorrentRSSRulesEnabled       string = "The number of enabled RSS auto-downloading rules"
+)
+
+// rssDateLayouts are the date formats found in the lastBuildDate of the feeds.
+var rssDateLayouts = []string{time.RFC1123Z, time.RFC1123, time.RFC3339}
+
+// RSSFeed is an RSS feed with its path in the folders, separated by `\`.
+type RSSFeed struct {
+	Path string
+	Feed API.RSSFeed
+}
+
+// RSS registers the metrics from rss/items and rss/rules.
+func RSS(feeds []RSSFeed, rules map[string]API.RSSRule, r *Registry) {
+	labels := []string{rssLabelFeed}
+
+	gauges := GaugeList{
+		{qbittorrentRSSFeedArticles, helpQbittorrentRSSFeedArticles, labels},
+		{qbittorrentRSSFeedUnreadArticles, helpQbittorrentRSSFeedUnreadArticles, labels},
+		{qbittorrentRSSFeedLastBuildDate, helpQbittorrentRSSFeedLastBuildDate, labels},
+		{qbittorrentRSSFeedError, helpQbittorrentRSSFeedError, labels},
+	}
+
+	metrics := registerGauge(&gauges, r)
+
+	for _, feed := range feeds {
+		feedLabels := map[string]string{rssLabelFeed: feed.Path}
+
+		unread := 0
+
+		for _, article := range feed.Feed.Articles {
+			if !article.IsRead {
+				unread++
+			}
+		}
+
+		hasError := 0.0
+		if feed.Feed.HasError {
+			hasError = 1.0
+		}
+
+		metrics[qbittorrentRSSFeedArticles].With(feedLabels).Set(float64(len(feed.Feed.Articles)))
+		metrics[qbittorrentRSSFeedUnreadArticles].With(feedLabels).Set(float64(unread))
+		metrics[qbittorrentRSSFeedError].With(feedLabels).Set(hasError)
+
+		if lastBuildDate, ok := parseRSSDate(feed.Feed.LastBuildDate); ok {
+			metrics[qbittorrentRSSFeedLastBuildDate].With(feedLabels).Set(float64(lastBuildDate.Unix()))
+		}
+	}
+
+	enabled := 0
+
+	for _, rule := range rules {
+		if rule.Enabled {
+			enabled++
+		}
+	}
+
+	ruleGauges := GaugeSet{
+		{qbittorrentRSSRules, helpQbittorrentRSSRules, float64(len(rules))},
+		{qbittorrentRSSRulesEnabled, helpQbittorrentRSSRulesEnabled, float64(enabled)},
+	}
+
+	registerGaugeGlobalAndSet(&ruleGauges, r)
+}
+
+func parseRSSDate(date string) (time.Time, bool) {
+	for _, layout := range rssDateLayouts {
+		if parsed, err := time.Parse(layout, date); err == nil {
+			return parsed, true
+		}
+	}
+
+	return time.Time{}, false
+}
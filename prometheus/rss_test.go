@@ -0,0 +1,59 @@
+package prom
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	API "qbit-exp/api"
+)
+
+func TestRSS(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry(nil)
+
+	RSS([]RSSFeed{
+		{
+			Path: `Linux\Distros`,
+			Feed: API.RSSFeed{ //nolint:exhaustruct
+				URL:           "https://example.com/rss",
+				LastBuildDate: "Mon, 02 Jan 2006 15:04:05 +0000",
+				Articles: []API.RSSArticle{
+					{ID: "1", IsRead: true},  //nolint:exhaustruct
+					{ID: "2", IsRead: false}, //nolint:exhaustruct
+					{ID: "3", IsRead: false}, //nolint:exhaustruct
+				},
+			},
+		},
+		{
+			Path: "Broken",
+			Feed: API.RSSFeed{URL: "https://example.com/broken", HasError: true}, //nolint:exhaustruct
+		},
+	}, map[string]API.RSSRule{
+		"enabled":  {Enabled: true},  //nolint:exhaustruct
+		"disabled": {Enabled: false}, //nolint:exhaustruct
+	}, registry)
+
+	var output bytes.Buffer
+
+	registry.WritePrometheus(&output)
+
+	for _, expected := range []string{
+		`qbittorrent_rss_feed_articles{feed="Linux\\Distros"} 3`,
+		`qbittorrent_rss_feed_unread_articles{feed="Linux\\Distros"} 2`,
+		`qbittorrent_rss_feed_last_build_date{feed="Linux\\Distros"} 1136214245`,
+		`qbittorrent_rss_feed_error{feed="Linux\\Distros"} 0`,
+		`qbittorrent_rss_feed_error{feed="Broken"} 1`,
+		`qbittorrent_rss_rules 2`,
+		`qbittorrent_rss_rules_enabled 1`,
+	} {
+		if !strings.Contains(output.String(), expected+"\n") {
+			t.Errorf("expected %s in\n%s", expected, output.String())
+		}
+	}
+
+	if strings.Contains(output.String(), `qbittorrent_rss_feed_last_build_date{feed="Broken"}`) {
+		t.Errorf("expected no last build date when not sent, got\n%s", output.String())
+	}
+}
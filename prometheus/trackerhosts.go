@@ -0,0 +1,127 @@
+package prom
+
+import (
+	API "qbit-exp/api"
+	"qbit-exp/internal"
+)
+
+const (
+	trackerLabelHost string = "host"
+
+	qbittorrentTrackerHostTorrents           string = metricCatTracker + "host_torrents"
+	qbittorrentTrackerHostSizeBytes          string = metricCatTracker + "host_size" + separator + torrentLabelBytes
+	qbittorrentTrackerHostUploadedBytes      string = metricCatTracker + "host_uploaded" + separator + torrentLabelBytes
+	qbittorrentTrackerHostRatio              string = metricCatTracker + "host_ratio"
+	qbittorrentTrackerHostTorrentsNotWorking string = metricCatTracker + "host_torrents_not_working"
+
+	helpQbittorrentTrackerHostTorrents           string = "The number of torrents by tracker host"
+	helpQbittorrentTrackerHostSizeBytes          string = "The total size of the torrents by tracker host" + BytesHelper
+	helpQbittorrentTrackerHostUploadedBytes      string = "The data uploaded by the current torrents by tracker host" + BytesHelper
+	helpQbittorrentTrackerHostRatio              string = "The mean ratio of the torrents by tracker host"
+	helpQbittorrentTrackerHostTorrentsNotWorking string = "The number of torrents by tracker host whose trackers on this host are all not working"
+
+	// trackerStatusNotWorking is the torrents/trackers status of the trackers
+	// which can't be reached.
+	trackerStatusNotWorking int = 4
+)
+
+// TrackerHosts registers the aggregates of the torrents by tracker host. The
+// trackers of each torrent come from the sync/maindata trackers; with the
+// qBittorrent versions not sending them, only the current tracker of the
+// torrents is known. The torrents not working are counted from statuses, the
+// torrents/trackers of every torrent, and left out when statuses is nil.
+func TrackerHosts(torrents API.SliceInfo, trackers map[string][]string, statuses []*API.Trackers, r *Registry) {
+	hostsByHash := make(map[string]map[string]struct{})
+
+	for trackerURL, hashes := range trackers {
+		host := internal.URLHost(trackerURL)
+		if host == "" {
+			continue
+		}
+
+		for _, hash := range hashes {
+			if hostsByHash[hash] == nil {
+				hostsByHash[hash] = make(map[string]struct{})
+			}
+
+			hostsByHash[hash][host] = struct{}{}
+		}
+	}
+
+	aggregates := make(map[string]*aggregate)
+
+	for _, torrent := range torrents {
+		hosts := hostsByHash[torrent.Hash]
+		if len(hosts) == 0 {
+			host := internal.URLHost(torrent.Tracker)
+			if host == "" {
+				continue
+			}
+
+			hosts = map[string]struct{}{host: {}}
+		}
+
+		for host := range hosts {
+			getAggregate(aggregates, host).add(torrent)
+		}
+	}
+
+	labels := []string{trackerLabelHost}
+
+	gauges := GaugeList{
+		{qbittorrentTrackerHostTorrents, helpQbittorrentTrackerHostTorrents, labels},
+		{qbittorrentTrackerHostSizeBytes, helpQbittorrentTrackerHostSizeBytes, labels},
+		{qbittorrentTrackerHostUploadedBytes, helpQbittorrentTrackerHostUploadedBytes, labels},
+		{qbittorrentTrackerHostRatio, helpQbittorrentTrackerHostRatio, labels},
+	}
+
+	metrics := registerGauge(&gauges, r)
+
+	for host, a := range aggregates {
+		hostLabels := map[string]string{trackerLabelHost: host}
+
+		metrics[qbittorrentTrackerHostTorrents].With(hostLabels).Set(float64(a.torrents))
+		metrics[qbittorrentTrackerHostSizeBytes].With(hostLabels).Set(float64(a.size))
+		metrics[qbittorrentTrackerHostUploadedBytes].With(hostLabels).Set(float64(a.uploaded))
+		metrics[qbittorrentTrackerHostRatio].With(hostLabels).Set(a.ratio / float64(a.torrents))
+	}
+
+	if statuses != nil {
+		trackerHostsNotWorking(statuses, r)
+	}
+}
+
+// trackerHostsNotWorking registers the number of torrents by tracker host
+// whose trackers on this host all have the not working status. The DHT, PeX
+// and LSD rows are skipped.
+func trackerHostsNotWorking(statuses []*API.Trackers, r *Registry) {
+	notWorking := make(map[string]int64)
+
+	for _, trackers := range statuses {
+		working := make(map[string]bool)
+
+		for _, tracker := range *trackers {
+			host := internal.URLHost(tracker.URL)
+			if host == "" || !internal.IsValidURL(tracker.URL) {
+				continue
+			}
+
+			working[host] = working[host] || tracker.Status != trackerStatusNotWorking
+		}
+
+		for host, isWorking := range working {
+			count := notWorking[host]
+			if !isWorking {
+				count++
+			}
+
+			notWorking[host] = count
+		}
+	}
+
+	vec := newGaugeVec(r, qbittorrentTrackerHostTorrentsNotWorking, helpQbittorrentTrackerHostTorrentsNotWorking, []string{trackerLabelHost})
+
+	for host, count := range notWorking {
+		vec.With(map[string]string{trackerLabelHost: host}).Set(float64(count))
+	}
+}
@@ -0,0 +1,97 @@
+package prom
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	API "qbit-exp/api"
+)
+
+func TestTrackerHosts(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry(nil)
+
+	torrents := API.SliceInfo{
+		{Hash: "a", Tracker: "https://Tracker.example/abcdef/announce", Size: 100, Uploaded: 200, Ratio: 2}, //nolint:exhaustruct
+		{Hash: "b", Tracker: "", Size: 50, Ratio: 1},                                                        //nolint:exhaustruct
+		{Hash: "c", Tracker: "udp://open.example:1337/announce", Size: 10},                                  //nolint:exhaustruct
+		{Hash: "d", Tracker: ""}, //nolint:exhaustruct
+	}
+
+	trackers := map[string][]string{
+		"https://tracker.example/abcdef/announce": {"a", "b"},
+		"udp://backup.example:6969/announce":      {"a"},
+	}
+
+	TrackerHosts(torrents, trackers, nil, registry)
+
+	var output bytes.Buffer
+
+	registry.WritePrometheus(&output)
+
+	for _, expected := range []string{
+		`qbittorrent_tracker_host_torrents{host="tracker.example"} 2`,
+		`qbittorrent_tracker_host_size_bytes{host="tracker.example"} 150`,
+		`qbittorrent_tracker_host_uploaded_bytes{host="tracker.example"} 200`,
+		`qbittorrent_tracker_host_ratio{host="tracker.example"} 1.5`,
+		`qbittorrent_tracker_host_torrents{host="backup.example"} 1`,
+		`qbittorrent_tracker_host_torrents{host="open.example"} 1`,
+	} {
+		if !strings.Contains(output.String(), expected+"\n") {
+			t.Errorf("expected %s in\n%s", expected, output.String())
+		}
+	}
+
+	if strings.Contains(output.String(), "abcdef") {
+		t.Errorf("expected no passkey in the labels, got\n%s", output.String())
+	}
+
+	if strings.Contains(output.String(), qbittorrentTrackerHostTorrentsNotWorking) {
+		t.Errorf("expected no %s without the tracker statuses, got\n%s", qbittorrentTrackerHostTorrentsNotWorking, output.String())
+	}
+}
+
+func TestTrackerHostsNotWorking(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry(nil)
+
+	result := []*API.Trackers{
+		// Working on one of the trackers of the host
+		{
+			{URL: "** [DHT] **", Status: 2},                         //nolint:exhaustruct
+			{URL: "https://tracker.example/a/announce", Status: 4},  //nolint:exhaustruct
+			{URL: "udp://tracker.example:1337/announce", Status: 2}, //nolint:exhaustruct
+			{URL: "udp://backup.example:6969/announce", Status: 4},  //nolint:exhaustruct
+		},
+		// Not working on any tracker of the host
+		{
+			{URL: "https://tracker.example/b/announce", Status: 4}, //nolint:exhaustruct
+		},
+		// Stopped torrent, not contacted
+		{
+			{URL: "https://tracker.example/c/announce", Status: 1}, //nolint:exhaustruct
+		},
+	}
+
+	TrackerHosts(nil, nil, result, registry)
+
+	var output bytes.Buffer
+
+	registry.WritePrometheus(&output)
+
+	for _, expected := range []string{
+		`qbittorrent_tracker_host_torrents_not_working{host="tracker.example"} 1`,
+		`qbittorrent_tracker_host_torrents_not_working{host="backup.example"} 1`,
+	} {
+		if !strings.Contains(output.String(), expected+"\n") {
+			t.Errorf("expected %s in\n%s", expected, output.String())
+		}
+	}
+
+	if strings.Contains(output.String(), "DHT") {
+		t.Errorf("expected no DHT host, got\n%s", output.String())
+	}
+}
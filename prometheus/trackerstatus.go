@@ -0,0 +1,106 @@
+package prom
+
+import (
+	"strconv"
+
+	API "qbit-exp/api"
+	"qbit-exp/internal"
+)
+
+const (
+	qbittorrentTrackerState    string = metricCatTracker + torrentLabelState
+	qbittorrentTrackerTorrents string = metricCatTracker + torrentLabelTorrents
+
+	helpQbittorrentTrackerState    string = "The current status of each tracker, one series per status (1 = current status)"
+	helpQbittorrentTrackerTorrents string = "The number of torrents by tracker and tracker status"
+)
+
+// trackerStates are the label values of the torrents/trackers statuses.
+var trackerStates = map[int]string{
+	0: "disabled",
+	1: "not_contacted",
+	2: "working",
+	3: "updating",
+	4: "not_working",
+}
+
+// pseudoTrackers are the label values of the DHT, PeX and LSD rows of
+// torrents/trackers, which don't have a URL.
+var pseudoTrackers = map[string]string{
+	"** [DHT] **": "dht",
+	"** [PeX] **": "pex",
+	"** [LSD] **": "lsd",
+}
+
+// trackerLabel returns the url label value of a torrents/trackers row, and
+// false for the rows to skip.
+func trackerLabel(trackerURL string) (string, bool) {
+	if pseudo, exists := pseudoTrackers[trackerURL]; exists {
+		return pseudo, true
+	}
+
+	if !internal.IsValidURL(trackerURL) {
+		return "", false
+	}
+
+	return trackerURLLabel(trackerURL), true
+}
+
+// trackerStateLabel returns the state label value of a tracker status.
+// Unknown statuses are labelled by their number.
+func trackerStateLabel(status int) string {
+	if state, exists := trackerStates[status]; exists {
+		return state
+	}
+
+	return strconv.Itoa(status)
+}
+
+// setTrackerState sets the series of every known status of a tracker, 1 for
+// its current status and 0 for the others.
+func setTrackerState(vec *GaugeVec, trackerURL string, status int) {
+	for known, state := range trackerStates {
+		value := 0.0
+		if known == status {
+			value = 1.0
+		}
+
+		vec.With(map[string]string{trackerLabelURL: trackerURL, torrentLabelState: state}).Set(value)
+	}
+
+	if _, known := trackerStates[status]; !known {
+		vec.With(map[string]string{trackerLabelURL: trackerURL, torrentLabelState: trackerStateLabel(status)}).Set(1)
+	}
+}
+
+// TrackerTorrents registers the number of torrents of each tracker by
+// tracker status, from the trackers of every torrent.
+func TrackerTorrents(result []*API.Trackers, r *Registry) {
+	counts := make(map[string]map[string]int)
+
+	for _, trackers := range result {
+		for _, tracker := range *trackers {
+			trackerURL, valid := trackerLabel(tracker.URL)
+			if !valid {
+				continue
+			}
+
+			if counts[trackerURL] == nil {
+				counts[trackerURL] = make(map[string]int, len(trackerStates))
+				for _, state := range trackerStates {
+					counts[trackerURL][state] = 0
+				}
+			}
+
+			counts[trackerURL][trackerStateLabel(tracker.Status)]++
+		}
+	}
+
+	torrents := newGaugeVec(r, qbittorrentTrackerTorrents, helpQbittorrentTrackerTorrents, []string{trackerLabelURL, torrentLabelState})
+
+	for trackerURL, states := range counts {
+		for state, count := range states {
+			torrents.With(map[string]string{trackerLabelURL: trackerURL, torrentLabelState: state}).Set(float64(count))
+		}
+	}
+}
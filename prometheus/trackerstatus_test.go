@@ -0,0 +1,81 @@
+package prom
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	API "qbit-exp/api"
+)
+
+func TestTrackersState(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry(nil)
+
+	Trackers([]*API.Trackers{
+		{
+			{URL: "** [DHT] **", Status: 2, NumPeers: 12, Tier: []byte(`""`)},      //nolint:exhaustruct
+			{URL: "http://tracker.example/announce", Status: 4, Tier: []byte("0")}, //nolint:exhaustruct
+			{URL: "http://new.example/announce", Status: 6, Tier: []byte("0")},     //nolint:exhaustruct
+		},
+	}, registry)
+
+	var output bytes.Buffer
+
+	registry.WritePrometheus(&output)
+
+	for _, expected := range []string{
+		`qbittorrent_tracker_peers{url="dht"} 12`,
+		`qbittorrent_tracker_state{state="working",url="dht"} 1`,
+		`qbittorrent_tracker_state{state="not_working",url="http://tracker.example/announce"} 1`,
+		`qbittorrent_tracker_state{state="not_contacted",url="http://tracker.example/announce"} 0`,
+		`qbittorrent_tracker_state{state="working",url="http://new.example/announce"} 0`,
+		`qbittorrent_tracker_state{state="6",url="http://new.example/announce"} 1`,
+	} {
+		if !strings.Contains(output.String(), expected+"\n") {
+			t.Errorf("expected %s in\n%s", expected, output.String())
+		}
+	}
+}
+
+func TestTrackerTorrents(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry(nil)
+
+	TrackerTorrents([]*API.Trackers{
+		{
+			{URL: "** [DHT] **", Status: 2},                     //nolint:exhaustruct
+			{URL: "http://tracker.example/announce", Status: 2}, //nolint:exhaustruct
+		},
+		{
+			{URL: "** [DHT] **", Status: 0},                     //nolint:exhaustruct
+			{URL: "http://tracker.example/announce", Status: 4}, //nolint:exhaustruct
+		},
+		{
+			{URL: "http://tracker.example/announce", Status: 4}, //nolint:exhaustruct
+			{URL: "invalid", Status: 4},                         //nolint:exhaustruct
+		},
+	}, registry)
+
+	var output bytes.Buffer
+
+	registry.WritePrometheus(&output)
+
+	for _, expected := range []string{
+		`qbittorrent_tracker_torrents{state="working",url="http://tracker.example/announce"} 1`,
+		`qbittorrent_tracker_torrents{state="not_working",url="http://tracker.example/announce"} 2`,
+		`qbittorrent_tracker_torrents{state="updating",url="http://tracker.example/announce"} 0`,
+		`qbittorrent_tracker_torrents{state="working",url="dht"} 1`,
+		`qbittorrent_tracker_torrents{state="disabled",url="dht"} 1`,
+	} {
+		if !strings.Contains(output.String(), expected+"\n") {
+			t.Errorf("expected %s in\n%s", expected, output.String())
+		}
+	}
+
+	if strings.Contains(output.String(), `url="invalid"`) || strings.Contains(output.String(), `url=""`) {
+		t.Errorf("expected the invalid trackers to be skipped, got\n%s", output.String())
+	}
+}
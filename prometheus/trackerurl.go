@@ -0,0 +1,94 @@
+package prom
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"qbit-exp/app"
+)
+
+const redacted string = "REDACTED"
+
+// secretQueryKeys are the query parameters holding the passkeys of the
+// private trackers.
+var secretQueryKeys = map[string]struct{}{
+	"apikey":       {},
+	"auth":         {},
+	"authkey":      {},
+	"key":          {},
+	"passkey":      {},
+	"pid":          {},
+	"secure":       {},
+	"token":        {},
+	"torrent_pass": {},
+	"uid":          {},
+}
+
+// secretPattern matches the path segments and query values that look like a
+// passkey.
+var secretPattern = regexp.MustCompile(`^[0-9A-Za-z_-]{16,}$`)
+
+// trackerURLLabel returns the label value of a tracker URL, according to
+// app.Exporter.TrackerURLLabel.
+func trackerURLLabel(trackerURL string) string {
+	return formatTrackerURL(trackerURL, app.Exporter.TrackerURLLabel)
+}
+
+// formatTrackerURL reduces a tracker URL to its scheme and host, masks its
+// passkeys or keeps it as is, depending on mode. Invalid URLs give an empty
+// value, unless the raw URLs are exported.
+func formatTrackerURL(trackerURL, mode string) string {
+	if trackerURL == "" || mode == app.TrackerURLRaw {
+		return trackerURL
+	}
+
+	parsed, err := url.Parse(trackerURL)
+	if err != nil || parsed.Host == "" {
+		return ""
+	}
+
+	if mode == app.TrackerURLHost {
+		return parsed.Scheme + "://" + parsed.Host
+	}
+
+	return redactTrackerURL(parsed)
+}
+
+// redactTrackerURL masks the credentials of a tracker URL and the path
+// segments and query values that look like passkeys.
+func redactTrackerURL(parsed *url.URL) string {
+	if parsed.User != nil {
+		parsed.User = url.User(redacted)
+	}
+
+	segments := strings.Split(parsed.Path, "/")
+	for i, segment := range segments {
+		if secretPattern.MatchString(segment) {
+			segments[i] = redacted
+		}
+	}
+
+	parsed.Path = strings.Join(segments, "/")
+	parsed.RawPath = ""
+
+	if parsed.RawQuery != "" {
+		query := parsed.Query()
+
+		for key, values := range query {
+			_, secret := secretQueryKeys[strings.ToLower(key)]
+
+			for i, value := range values {
+				if secret || secretPattern.MatchString(value) {
+					values[i] = redacted
+				}
+			}
+		}
+
+		parsed.RawQuery = query.Encode()
+	}
+
+	parsed.Fragment = ""
+
+	return parsed.String()
+}
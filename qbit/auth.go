@@ -8,42 +8,89 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	API "qbit-exp/api"
-	app "qbit-exp/app"
 	"qbit-exp/logger"
 )
 
+const loginPath string = baseAPIRUL + "auth/login"
+
+// Auth logs in every instance that doesn't use an API key. With a single
+// instance, it returns its AuthError or BannedError: the exporter can't
+// recover from them without a change of settings. With several instances,
+// they are only logged so that the other instances are still scraped, and the
+// instance reports qbittorrent_up 0. Other errors are only logged, the
+// instances being logged in again on the next scrape.
 func Auth() error {
-	ctx, cancel := context.WithTimeout(context.Background(), app.QBittorrent.Timeout)
+	for _, client := range clients {
+		if client.settings.APIKey != nil {
+			continue
+		}
+
+		err := client.Auth()
+		if !IsAuthFailure(err) {
+			continue
+		}
+
+		if len(clients) == 1 {
+			return err
+		}
+
+		logger.Error(fmt.Sprintf("Can't log in to the instance %s, it is reported down: %s", client.settings.Name, err))
+	}
+
+	return nil
+}
+
+// IsAuthFailure reports whether err is an AuthError or a BannedError.
+func IsAuthFailure(err error) bool {
+	var authError *API.AuthError
+
+	var bannedError *API.BannedError
+
+	return errors.As(err, &authError) || errors.As(err, &bannedError)
+}
+
+// Auth logs in the instance and stores the session cookie.
+func (c *Client) Auth() error {
+	loginUrl := c.settings.BaseUrl + loginPath
+
+	start := time.Now()
+	err := c.login(loginUrl)
+	c.stats.observeRequest(apiEndpoint(loginUrl), time.Since(start), err != nil)
+
+	return err
+}
+
+func (c *Client) login(loginUrl string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.settings.Timeout)
 	defer cancel()
 
 	params := url.Values{
-		"username": {app.QBittorrent.LegacyAuth.Username},
-		"password": {app.QBittorrent.LegacyAuth.Password},
+		"username": {c.settings.LegacyAuth.Username},
+		"password": {c.settings.LegacyAuth.Password},
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, app.QBittorrent.BaseUrl+"/api/v2/auth/login", strings.NewReader(params.Encode()))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, loginUrl, strings.NewReader(params.Encode()))
 	if err != nil {
-		panic(fmt.Sprintf("%s %s", API.ErrorWithUrl, err.Error()))
+		return fmt.Errorf("%s %w", API.ErrorWithUrl, err)
 	}
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	if app.QBittorrent.BasicAuth != nil {
-		req.SetBasicAuth(app.QBittorrent.BasicAuth.Username, app.QBittorrent.BasicAuth.Password)
+	if c.settings.BasicAuth != nil {
+		req.SetBasicAuth(c.settings.BasicAuth.Username, c.settings.BasicAuth.Password)
 	}
 
-	resp, err := app.HttpClient.Do(req)
+	resp, err := c.settings.Client().Do(req)
 
 	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
-		logger.Error(API.QbittorrentTimeOut)
-
-		return context.DeadlineExceeded
+		err = context.DeadlineExceeded
 	}
 
 	if err != nil {
-		err := fmt.Errorf("%s: %w", API.ErrorConnect, err)
+		err := &API.UnreachableError{Err: err}
 		logger.Error(err.Error())
 
 		return err
@@ -59,16 +106,22 @@ func Auth() error {
 	if resp.StatusCode == http.StatusOK {
 		body, err := io.ReadAll(resp.Body)
 		if err != nil {
-			panic("Error reading the body " + err.Error())
+			err := fmt.Errorf("error reading the body: %w", err)
+			logger.Error(err.Error())
+
+			return err
 		}
 
 		if string(body) == "Fails." {
-			panic("Authentication Error, check your qBittorrent username / password")
+			err := &API.AuthError{Message: "check your qBittorrent username / password"}
+			logger.Error(err.Error())
+
+			return err
 		}
 	} else if resp.StatusCode != http.StatusNoContent {
-		err := fmt.Errorf("authentication failed, status code: %d", resp.StatusCode)
-		if resp.StatusCode == http.StatusForbidden && app.QBittorrent.LegacyAuth.Cookie.Value == nil {
-			panic(err.Error() + ". qBittorrent has probably banned your IP")
+		var err error = fmt.Errorf("authentication failed, status code: %d", resp.StatusCode)
+		if resp.StatusCode == http.StatusForbidden && c.cookie() == nil {
+			err = &API.BannedError{StatusCode: resp.StatusCode}
 		}
 
 		logger.Error(err.Error())
@@ -76,11 +129,37 @@ func Auth() error {
 		return err
 	}
 
-	logger.Info("New cookie for auth stored")
-
 	cookie := resp.Header.Get("Set-Cookie")
-	cookieValue := strings.Split(strings.Split(cookie, ";")[0], "=")[1]
-	app.QBittorrent.LegacyAuth.Cookie.Value = &cookieValue
+
+	_, cookieValue, found := strings.Cut(strings.Split(cookie, ";")[0], "=")
+	if !found {
+		err := errors.New("authentication failed, no session cookie in the response")
+		logger.Error(err.Error())
+
+		return err
+	}
+
+	c.setCookie(&cookieValue)
+	logger.Info("New cookie for auth stored")
 
 	return nil
 }
+
+// cookie returns the session cookie, nil when not logged in.
+func (c *Client) cookie() *string {
+	if c.settings.LegacyAuth == nil {
+		return nil
+	}
+
+	c.cookieMu.RLock()
+	defer c.cookieMu.RUnlock()
+
+	return c.settings.LegacyAuth.Cookie.Value
+}
+
+func (c *Client) setCookie(value *string) {
+	c.cookieMu.Lock()
+	defer c.cookieMu.Unlock()
+
+	c.settings.LegacyAuth.Cookie.Value = value
+}
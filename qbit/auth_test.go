@@ -2,7 +2,9 @@ package qbit
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
+	"errors"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
@@ -18,7 +20,7 @@ import (
 
 var buff = &bytes.Buffer{}
 
-const defaultTimeout time.Duration = 10 * time.Millisecond
+const defaultTimeout time.Duration = 100 * time.Millisecond
 
 var legacyAuth = app.LegacyAuth{
 	Username: "testuser",
@@ -63,7 +65,7 @@ func TestAuthSuccess(t *testing.T) {
 	app.QBittorrent.LegacyAuth = &legacyAuth
 	app.QBittorrent.Timeout = defaultTimeout
 
-	err := Auth()
+	err := newTestClient().Auth()
 	if err != nil {
 		t.Errorf("There was an error: %s", err.Error())
 	}
@@ -88,19 +90,107 @@ func TestAuthFail(t *testing.T) {
 	app.QBittorrent.LegacyAuth = &wronglegacyAuth
 	app.QBittorrent.Timeout = defaultTimeout
 
-	defer func() {
-		if r := recover(); r == nil {
-			t.Errorf("expected panic for authentication failure, got none")
+	err := newTestClient().Auth()
+
+	var authError *API.AuthError
+	if !errors.As(err, &authError) {
+		t.Errorf("expected an AuthError, got %v", err)
+	}
+
+	if !IsAuthFailure(err) {
+		t.Errorf("expected %v to be an auth failure", err)
+	}
+}
+
+func TestAuthInstances(t *testing.T) { //nolint:paralleltest
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("Fails."))
+	}))
+	defer failing.Close()
+
+	working := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "SID=abc123; Path=/")
+		_, _ = w.Write([]byte("Ok."))
+	}))
+	defer working.Close()
+
+	newInstance := func(name, baseUrl string) *app.QBittorrentSettings {
+		return &app.QBittorrentSettings{ //nolint:exhaustruct
+			Name:       name,
+			BaseUrl:    baseUrl,
+			LegacyAuth: &app.LegacyAuth{Username: "user", Password: "wrong", Cookie: app.Cookie{Key: "SID", Value: nil}},
+			Timeout:    defaultTimeout,
+			HttpClient: &http.Client{}, //nolint:exhaustruct
 		}
-	}()
+	}
+
+	defer SetInstances(nil)
+
+	SetInstances([]*app.QBittorrentSettings{newInstance("", failing.URL)})
 
 	err := Auth()
-	if err == nil {
-		t.Errorf("There wasn't an error")
+	if !IsAuthFailure(err) {
+		t.Errorf("expected an auth failure with a single instance, got %v", err)
+	}
+
+	SetInstances([]*app.QBittorrentSettings{newInstance("seedbox", failing.URL), newInstance("home", working.URL)})
+
+	err = Auth()
+	if err != nil {
+		t.Errorf("expected no error with several instances, got %v", err)
+	}
+
+	if clients[1].cookie() == nil {
+		t.Error("expected the other instance to be logged in")
 	}
 }
 
-func TestAuthInvalidUrl(t *testing.T) {
+func TestAuthBanned(t *testing.T) {
+	t.Cleanup(resetState)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	notLoggedIn := legacyAuth
+	notLoggedIn.Cookie.Value = nil
+
+	app.QBittorrent.BaseUrl = ts.URL
+	app.QBittorrent.LegacyAuth = &notLoggedIn
+	app.QBittorrent.Timeout = defaultTimeout
+
+	err := newTestClient().Auth()
+
+	var bannedError *API.BannedError
+	if !errors.As(err, &bannedError) || bannedError.StatusCode != http.StatusForbidden {
+		t.Errorf("expected a BannedError, got %v", err)
+	}
+}
+
+func TestAuthUnreachable(t *testing.T) {
+	t.Cleanup(resetState)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ts.Close()
+
+	app.QBittorrent.BaseUrl = ts.URL
+	app.QBittorrent.LegacyAuth = &legacyAuth
+	app.QBittorrent.Timeout = defaultTimeout
+
+	err := newTestClient().Auth()
+
+	var unreachableError *API.UnreachableError
+	if !errors.As(err, &unreachableError) {
+		t.Errorf("expected an UnreachableError, got %v", err)
+	}
+
+	if IsAuthFailure(err) {
+		t.Errorf("expected %v not to be an auth failure", err)
+	}
+}
+
+func TestAuthMissingCookie(t *testing.T) {
 	t.Cleanup(resetState)
 
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -112,13 +202,10 @@ func TestAuthInvalidUrl(t *testing.T) {
 	app.QBittorrent.LegacyAuth = &legacyAuth
 	app.QBittorrent.Timeout = defaultTimeout
 
-	defer func() {
-		if r := recover(); r == nil {
-			t.Errorf("expected panic for invalid URL")
-		}
-	}()
-
-	_ = Auth()
+	err := newTestClient().Auth()
+	if err == nil {
+		t.Errorf("expected an error without session cookie")
+	}
 }
 
 func TestAuthTimeout(t *testing.T) {
@@ -129,7 +216,11 @@ func TestAuthTimeout(t *testing.T) {
 
 	app.QBittorrent.BaseUrl = ts.URL
 	app.QBittorrent.Timeout = defaultTimeout
-	_ = Auth()
+	err := newTestClient().Auth()
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected DeadlineExceeded, got %v", err)
+	}
 
 	if !strings.Contains(buff.String(), API.QbittorrentTimeOut) {
 		t.Errorf("expected timeout log, got: %s", buff.String())
@@ -146,7 +237,7 @@ func TestUnknownStatusCode(t *testing.T) {
 
 	app.QBittorrent.BaseUrl = ts.URL
 	app.QBittorrent.Timeout = defaultTimeout
-	_ = Auth()
+	_ = newTestClient().Auth()
 
 	if !strings.Contains(buff.String(), strconv.Itoa(http.StatusCreated)) {
 		t.Errorf("expected %d, got: %s", http.StatusCreated, buff.String())
@@ -188,7 +279,7 @@ func TestAuth_BasicAuthSuccess(t *testing.T) {
 		Password: httpBasicAuthPassword,
 	}
 
-	err := Auth()
+	err := newTestClient().Auth()
 	if err != nil {
 		t.Errorf("There was an error: %s", err.Error())
 	}
@@ -235,7 +326,7 @@ func TestAuth_BasicAuthInvalidAuthentication(t *testing.T) {
 		Password: httpBasicAuthPassword,
 	}
 
-	err := Auth()
+	err := newTestClient().Auth()
 	if err == nil {
 		t.Fatalf("Expected error due to invalid authentication, but got nil")
 	}
@@ -260,7 +351,7 @@ func TestAuthStatusNoContent(t *testing.T) {
 	app.QBittorrent.LegacyAuth = &legacyAuth
 	app.QBittorrent.Timeout = defaultTimeout
 
-	err := Auth()
+	err := newTestClient().Auth()
 	if err != nil {
 		t.Fatalf("unexpected error for 204 status: %v", err)
 	}
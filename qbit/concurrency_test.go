@@ -0,0 +1,231 @@
+package qbit
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	API "qbit-exp/api"
+	"qbit-exp/app"
+	prom "qbit-exp/prometheus"
+)
+
+// fakeQbittorrent is a qBittorrent stand-in serving the requests of a scrape.
+// It fails the test when sync/maindata requests overlap or when the rid sent
+// is not the last one returned.
+type fakeQbittorrent struct {
+	t *testing.T
+
+	// hold, when set, blocks the sync/maindata requests until it is closed.
+	hold     chan struct{}
+	received chan struct{}
+
+	mu              sync.Mutex
+	rid             int64
+	maindataCalls   int
+	maindataRunning atomic.Int32
+}
+
+func newFakeQbittorrent(t *testing.T) (*fakeQbittorrent, *httptest.Server) {
+	t.Helper()
+
+	fake := &fakeQbittorrent{ //nolint:exhaustruct
+		t:        t,
+		received: make(chan struct{}, 1),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/app/webapiVersion", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("2.11.2"))
+	})
+	mux.HandleFunc("/api/v2/app/version", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("v5.0.0"))
+	})
+	mux.HandleFunc("/api/v2/app/preferences", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("{}"))
+	})
+	mux.HandleFunc("/api/v2/transfer/info", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"connection_status":"connected","dht_nodes":10}`))
+	})
+	mux.HandleFunc("/api/v2/sync/maindata", fake.maindata)
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return fake, server
+}
+
+func (f *fakeQbittorrent) maindata(w http.ResponseWriter, r *http.Request) {
+	if f.maindataRunning.Add(1) > 1 {
+		f.t.Error("sync/maindata requests overlap")
+	}
+	defer f.maindataRunning.Add(-1)
+
+	select {
+	case f.received <- struct{}{}:
+	default:
+	}
+
+	if f.hold != nil {
+		<-f.hold
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.maindataCalls++
+
+	rid, _ := strconv.ParseInt(r.URL.Query().Get("rid"), 10, 64)
+	if rid != 0 && rid != f.rid {
+		f.t.Errorf("expected rid %d, got %d", f.rid, rid)
+	}
+
+	f.rid++
+
+	torrent, _ := json.Marshal(map[string]any{"name": "torrent", "state": "uploading", "size": 1024})
+
+	_ = json.NewEncoder(w).Encode(API.DeltaMainData{ //nolint:exhaustruct
+		Rid:        f.rid,
+		FullUpdate: rid == 0,
+		Torrents:   map[string]json.RawMessage{"hash": torrent},
+	})
+}
+
+func (f *fakeQbittorrent) calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.maindataCalls
+}
+
+func newFakeClient(baseUrl string) *Client {
+	apiKey := "apiKey"
+
+	return NewClient(&app.QBittorrentSettings{ //nolint:exhaustruct
+		BaseUrl:             baseUrl,
+		APIKey:              &apiKey,
+		Timeout:             time.Second,
+		FullRefreshInterval: app.DefaultFullRefreshInterval,
+		HttpClient:          &http.Client{}, //nolint:exhaustruct
+	})
+}
+
+func TestConcurrentScrapesShareCollection(t *testing.T) {
+	t.Parallel()
+
+	const scrapes = 5
+
+	fake, server := newFakeQbittorrent(t)
+	fake.hold = make(chan struct{})
+
+	client := newFakeClient(server.URL)
+
+	var wg sync.WaitGroup
+
+	sets := make([]*prom.Registry, scrapes)
+	errs := make([]error, scrapes)
+
+	for i := range scrapes {
+		sets[i] = prom.NewRegistry(nil)
+
+		wg.Go(func() {
+			errs[i] = client.AllRequests(sets[i])
+		})
+
+		if i == 0 {
+			// Wait for the first collection to be in progress.
+			<-fake.received
+		}
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(fake.hold)
+	wg.Wait()
+
+	if calls := fake.calls(); calls != 1 {
+		t.Errorf("expected concurrent scrapes to share 1 collection, got %d", calls)
+	}
+
+	for i := range scrapes {
+		if errs[i] != nil {
+			t.Fatalf("scrape %d failed: %v", i, errs[i])
+		}
+
+		var output bytes.Buffer
+
+		sets[i].WritePrometheus(&output)
+
+		if !strings.Contains(output.String(), `qbittorrent_torrent_size_bytes{`) {
+			t.Errorf("scrape %d is missing the torrent metrics:\n%s", i, output.String())
+		}
+	}
+}
+
+func TestConcurrentScrapesKeepDeltaSyncConsistent(t *testing.T) {
+	t.Parallel()
+
+	const (
+		scrapers   = 8
+		iterations = 10
+	)
+
+	fake, server := newFakeQbittorrent(t)
+	client := newFakeClient(server.URL)
+
+	var wg sync.WaitGroup
+
+	for range scrapers {
+		wg.Go(func() {
+			for range iterations {
+				if err := client.AllRequests(prom.NewRegistry(nil)); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}
+		})
+	}
+
+	wg.Wait()
+
+	if calls := fake.calls(); calls == 0 || calls > scrapers*iterations {
+		t.Errorf("unexpected number of collections: %d", calls)
+	}
+
+	if torrents := client.syncState.TorrentCount(); torrents != 1 {
+		t.Errorf("expected 1 torrent in the sync state, got %d", torrents)
+	}
+}
+
+func TestCollectionAbortedByPanic(t *testing.T) {
+	t.Parallel()
+
+	client := newFakeClient("http://localhost")
+
+	current := &collection{done: make(chan struct{}), registry: prom.NewRegistry(nil), err: errCollectionAborted}
+	client.inFlight = current
+
+	func() {
+		defer func() {
+			_ = recover()
+		}()
+
+		client.settings.FullRefreshInterval = 0 // integer divide by zero
+		client.runCollection(current)
+	}()
+
+	select {
+	case <-current.done:
+	default:
+		t.Fatal("expected waiters to be released after a panic")
+	}
+
+	if current.err == nil || client.inFlight != nil {
+		t.Errorf("expected the aborted collection to be cleared, got %v", current.err)
+	}
+}
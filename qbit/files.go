@@ -0,0 +1,79 @@
+package qbit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	API "qbit-exp/api"
+	"qbit-exp/app"
+	"qbit-exp/logger"
+	prom "qbit-exp/prometheus"
+)
+
+// getFiles collects the files of the selected torrents, at most
+// FILES_MAX_TORRENTS of them.
+func (c *Client) getFiles(torrents API.SliceInfo, r *prom.Registry) {
+	selected := selectTorrents(torrents, app.Exporter.Files.Torrents, isIncomplete)
+	selected = limitTorrents(selected, app.Exporter.Files.MaxTorrents)
+
+	results := make([]*prom.TorrentFiles, len(selected))
+
+	forEachTorrent(selected, func(i int, torrent API.Info) {
+		files, err := c.fetchTorrentFiles(torrent.Hash)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Can't get the files of %s: %s", torrent.Name, err))
+
+			return
+		}
+
+		results[i] = &prom.TorrentFiles{Torrent: torrent, Files: files}
+	})
+
+	torrentsFiles := make([]prom.TorrentFiles, 0, len(results))
+
+	for _, result := range results {
+		if result != nil {
+			torrentsFiles = append(torrentsFiles, *result)
+		}
+	}
+
+	prom.Files(torrentsFiles, app.Exporter.Files.MaxPerTorrent, r)
+}
+
+// fetchTorrentFiles fetches the files of a torrent from torrents/files.
+func (c *Client) fetchTorrentFiles(hash string) ([]API.File, error) {
+	url := c.createUrl(baseAPIRUL + "torrents/files")
+
+	queryParams := &[]QueryParams{
+		{Key: "hash", Value: hash},
+	}
+
+	body, retry, err := c.apiRequest(url, http.MethodGet, queryParams)
+	if retry {
+		logger.Debug("Retrying torrent files request...")
+
+		body, _, err = c.apiRequest(url, http.MethodGet, queryParams)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var files []API.File
+
+	err = json.Unmarshal(body, &files)
+	if err != nil {
+		errMsg := fmt.Errorf("%s %s: %w", unmarshError, url, err)
+		errorHelper(&body, &errMsg, &url)
+
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// isIncomplete reports whether the torrent has data left to download.
+func isIncomplete(torrent API.Info) bool {
+	return torrent.AmountLeft > 0
+}
@@ -0,0 +1,79 @@
+package qbit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	API "qbit-exp/api"
+	"qbit-exp/app"
+	prom "qbit-exp/prometheus"
+)
+
+func TestFetchTorrentFiles(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/torrents/files" || r.URL.Query().Get("hash") != "hash1" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+
+		_, _ = w.Write([]byte(`[
+			{"index":0,"name":"dir/a.mkv","size":1024,"progress":0.5,"priority":1,"availability":0.75},
+			{"index":1,"name":"dir/b.nfo","size":10,"progress":0,"priority":0,"availability":-1}
+		]`))
+	}))
+	defer server.Close()
+
+	files, err := newFakeClient(server.URL).fetchTorrentFiles("hash1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(files) != 2 || files[0].Name != "dir/a.mkv" || files[0].Availability != 0.75 || files[1].Priority != 0 {
+		t.Errorf("unexpected files %+v", files)
+	}
+}
+
+func TestFetchTorrentFilesInvalidJson(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{`))
+	}))
+	defer server.Close()
+
+	if _, err := newFakeClient(server.URL).fetchTorrentFiles("hash1"); err == nil {
+		t.Error("expected an error for an invalid response")
+	}
+}
+
+func TestGetFilesMaxTorrents(t *testing.T) {
+	previous := app.Exporter.Files
+	app.Exporter.Files = app.FilesSettings{Torrents: nil, MaxPerTorrent: 100, MaxTorrents: 2}
+
+	defer func() { app.Exporter.Files = previous }()
+
+	var requests atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	torrents := API.SliceInfo{
+		{Hash: "hash1", AmountLeft: 10, AddedOn: 1}, //nolint:exhaustruct
+		{Hash: "hash2", AmountLeft: 10, AddedOn: 2}, //nolint:exhaustruct
+		{Hash: "hash3", AmountLeft: 10, AddedOn: 3}, //nolint:exhaustruct
+		{Hash: "complete", AddedOn: 4},              //nolint:exhaustruct
+	}
+
+	newFakeClient(server.URL).getFiles(torrents, prom.NewRegistry(nil))
+
+	if requests.Load() != 2 {
+		t.Errorf("expected the files of 2 torrents to be requested, got %d", requests.Load())
+	}
+}
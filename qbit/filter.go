@@ -0,0 +1,124 @@
+package qbit
+
+import (
+	"slices"
+	"strings"
+
+	API "qbit-exp/api"
+	"qbit-exp/app"
+	"qbit-exp/internal"
+)
+
+// filterTorrents returns the torrents getting per-torrent series, according
+// to filter. The tracker hosts of the torrents come from trackers, the
+// sync/maindata trackers, and from their current tracker.
+func filterTorrents(torrents API.SliceInfo, trackers map[string][]string, filter *app.TorrentFilter) API.SliceInfo {
+	if filter.Include.IsEmpty() && filter.Exclude.IsEmpty() {
+		return torrents
+	}
+
+	var hostsByHash map[string][]string
+
+	if len(filter.Include.TrackerHosts) > 0 || len(filter.Exclude.TrackerHosts) > 0 {
+		hostsByHash = make(map[string][]string)
+
+		for trackerURL, hashes := range trackers {
+			host := internal.URLHost(trackerURL)
+			for _, hash := range hashes {
+				hostsByHash[hash] = append(hostsByHash[hash], host)
+			}
+		}
+	}
+
+	filtered := make(API.SliceInfo, 0, len(torrents))
+
+	for _, torrent := range torrents {
+		hosts := slices.Concat(hostsByHash[torrent.Hash], []string{internal.URLHost(torrent.Tracker)})
+
+		if !filter.Include.IsEmpty() && !matchesAll(&filter.Include, torrent, hosts) {
+			continue
+		}
+
+		if matchesSome(&filter.Exclude, torrent, hosts) {
+			continue
+		}
+
+		filtered = append(filtered, torrent)
+	}
+
+	return filtered
+}
+
+// isExported returns the predicate telling whether a torrent is one of the
+// exported torrents, nil when every torrent is exported.
+func isExported(torrents, exported API.SliceInfo) func(API.Info) bool {
+	if len(exported) == len(torrents) {
+		return nil
+	}
+
+	hashes := make(map[string]struct{}, len(exported))
+	for _, torrent := range exported {
+		hashes[torrent.Hash] = struct{}{}
+	}
+
+	return func(torrent API.Info) bool {
+		_, exists := hashes[torrent.Hash]
+
+		return exists
+	}
+}
+
+// matchesAll tells whether a torrent matches every non-empty field of m.
+func matchesAll(m *app.TorrentMatcher, torrent API.Info, hosts []string) bool {
+	for _, matches := range torrentMatches(m, torrent, hosts) {
+		if matches != nil && !*matches {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchesSome tells whether a torrent matches one of the fields of m.
+func matchesSome(m *app.TorrentMatcher, torrent API.Info, hosts []string) bool {
+	for _, matches := range torrentMatches(m, torrent, hosts) {
+		if matches != nil && *matches {
+			return true
+		}
+	}
+
+	return false
+}
+
+// torrentMatches tells whether a torrent matches each field of m, nil for the
+// empty fields.
+func torrentMatches(m *app.TorrentMatcher, torrent API.Info, hosts []string) []*bool {
+	matches := func(values []string, match func(string) bool) *bool {
+		if len(values) == 0 {
+			return nil
+		}
+
+		result := slices.ContainsFunc(values, match)
+
+		return &result
+	}
+
+	var tags []string
+	if torrent.Tags != "" {
+		tags = strings.Split(torrent.Tags, ", ")
+	}
+
+	var name *bool
+	if m.Name != nil {
+		name = new(m.Name.MatchString(torrent.Name))
+	}
+
+	return []*bool{
+		matches(m.Categories, func(category string) bool { return category == torrent.Category }),
+		matches(m.Tags, func(tag string) bool { return slices.Contains(tags, tag) }),
+		matches(m.States, func(state string) bool { return state == torrent.State }),
+		matches(m.TrackerHosts, func(host string) bool { return slices.Contains(hosts, host) }),
+		matches(m.SavePaths, func(prefix string) bool { return strings.HasPrefix(torrent.SavePath, prefix) }),
+		name,
+	}
+}
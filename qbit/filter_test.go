@@ -0,0 +1,90 @@
+package qbit
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+
+	API "qbit-exp/api"
+	"qbit-exp/app"
+)
+
+func TestFilterTorrents(t *testing.T) {
+	t.Parallel()
+
+	torrents := API.SliceInfo{
+		{Hash: "a", Name: "Movie", Category: "movies", State: "uploading", SavePath: "/data/movies/a"},                           //nolint:exhaustruct
+		{Hash: "b", Name: "Show", Category: "tv", Tags: "private, hd", State: "stalledUP", Tracker: "https://private.example/x"}, //nolint:exhaustruct
+		{Hash: "c", Name: "Linux.iso", Category: "software", State: "downloading", SavePath: "/data/iso/c"},                      //nolint:exhaustruct
+		{Hash: "d", Name: "Sample movie", Category: "movies", State: "stoppedUP", SavePath: "/tmp/d"},                            //nolint:exhaustruct
+	}
+
+	trackers := map[string][]string{
+		"udp://open.example:1337/announce": {"a", "c"},
+	}
+
+	tests := []struct {
+		name     string
+		filter   app.TorrentFilter
+		expected []string
+	}{
+		{"No filter", app.TorrentFilter{}, []string{"a", "b", "c", "d"}}, //nolint:exhaustruct
+		{
+			"Include categories",
+			app.TorrentFilter{Include: app.TorrentMatcher{Categories: []string{"movies", "tv"}}}, //nolint:exhaustruct
+			[]string{"a", "b", "d"},
+		},
+		{
+			"Include category and save path",
+			app.TorrentFilter{Include: app.TorrentMatcher{Categories: []string{"movies"}, SavePaths: []string{"/data/"}}}, //nolint:exhaustruct
+			[]string{"a"},
+		},
+		{
+			"Exclude tag or state",
+			app.TorrentFilter{Exclude: app.TorrentMatcher{Tags: []string{"private"}, States: []string{"stoppedUP"}}}, //nolint:exhaustruct
+			[]string{"a", "c"},
+		},
+		{
+			"Include tracker hosts",
+			app.TorrentFilter{Include: app.TorrentMatcher{TrackerHosts: []string{"open.example", "private.example"}}}, //nolint:exhaustruct
+			[]string{"a", "b", "c"},
+		},
+		{
+			"Exclude name",
+			app.TorrentFilter{Exclude: app.TorrentMatcher{Name: regexp.MustCompile(`(?i)sample`)}}, //nolint:exhaustruct
+			[]string{"a", "b", "c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			filtered := filterTorrents(torrents, trackers, &tt.filter)
+
+			hashes := make([]string, 0, len(filtered))
+			for _, torrent := range filtered {
+				hashes = append(hashes, torrent.Hash)
+			}
+
+			if !slices.Equal(hashes, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, hashes)
+			}
+		})
+	}
+}
+
+func TestIsExported(t *testing.T) {
+	t.Parallel()
+
+	torrents := API.SliceInfo{{Hash: "a"}, {Hash: "b"}} //nolint:exhaustruct
+
+	if isExported(torrents, torrents) != nil {
+		t.Error("expected no predicate when every torrent is exported")
+	}
+
+	exported := isExported(torrents, torrents[:1])
+	if !exported(torrents[0]) || exported(torrents[1]) {
+		t.Error("expected only the first torrent to be exported")
+	}
+}
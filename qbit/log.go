@@ -0,0 +1,129 @@
+package qbit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	API "qbit-exp/api"
+	"qbit-exp/logger"
+	prom "qbit-exp/prometheus"
+)
+
+// getLog fetches the log entries written since the last scrape and registers
+// the log counters. The new warnings and critical entries are also written to
+// the exporter log. An error doesn't fail the scrape.
+func (c *Client) getLog(r *prom.Registry) {
+	err := c.getMainLog()
+	if err != nil {
+		logger.Warn(fmt.Sprintf("Can't get the main log: %s", err))
+	}
+
+	err = c.getPeerLog()
+	if err != nil {
+		logger.Warn(fmt.Sprintf("Can't get the peer log: %s", err))
+	}
+
+	prom.Log(c.logState.GetEntriesByType(), c.logState.GetBannedPeers(), r)
+}
+
+// getMainLog counts the new entries of the main log, read again from the
+// start when qBittorrent restarted.
+func (c *Client) getMainLog() error {
+	lastMainID := c.logState.GetLastMainID()
+
+	var entries []API.LogEntry
+
+	err := c.fetchLog("log/main", lastMainID, &entries)
+	if err != nil {
+		return err
+	}
+
+	added, restarted := c.logState.ApplyMain(entries)
+	if restarted {
+		logger.Debug("The main log started again, reading it from the start")
+
+		lastMainID = c.logState.GetLastMainID()
+
+		err = c.fetchLog("log/main", lastMainID, &entries)
+		if err != nil {
+			return err
+		}
+
+		added, _ = c.logState.ApplyMain(entries)
+	}
+
+	// The whole log is only counted, its entries are not new
+	if lastMainID >= 0 {
+		forwardLogEntries(added)
+	}
+
+	return nil
+}
+
+// getPeerLog counts the new blocked peers of the peer log, read again from
+// the start when qBittorrent restarted.
+func (c *Client) getPeerLog() error {
+	var entries []API.PeerLogEntry
+
+	err := c.fetchLog("log/peers", c.logState.GetLastPeersID(), &entries)
+	if err != nil {
+		return err
+	}
+
+	if c.logState.ApplyPeers(entries) {
+		logger.Debug("The peer log started again, reading it from the start")
+
+		err = c.fetchLog("log/peers", c.logState.GetLastPeersID(), &entries)
+		if err != nil {
+			return err
+		}
+
+		c.logState.ApplyPeers(entries)
+	}
+
+	return nil
+}
+
+// fetchLog fetches the entries of a log newer than lastKnownID.
+func (c *Client) fetchLog(path string, lastKnownID int64, entries any) error {
+	url := c.createUrl(baseAPIRUL + path)
+
+	queryParams := &[]QueryParams{
+		{Key: "last_known_id", Value: strconv.FormatInt(lastKnownID, 10)},
+	}
+
+	body, retry, err := c.apiRequest(url, http.MethodGet, queryParams)
+	if retry {
+		logger.Debug("Retrying log request...")
+
+		body, _, err = c.apiRequest(url, http.MethodGet, queryParams)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(body, entries)
+	if err != nil {
+		errMsg := fmt.Errorf("%s %s: %w", unmarshError, url, err)
+		errorHelper(&body, &errMsg, &url)
+
+		return err
+	}
+
+	return nil
+}
+
+// forwardLogEntries writes the warnings and critical entries to the exporter log.
+func forwardLogEntries(entries []API.LogEntry) {
+	for _, entry := range entries {
+		switch entry.Type {
+		case API.LogTypeWarning:
+			logger.Warn("qBittorrent: " + entry.Message)
+		case API.LogTypeCritical:
+			logger.Error("qBittorrent: " + entry.Message)
+		}
+	}
+}
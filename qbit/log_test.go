@@ -0,0 +1,123 @@
+package qbit
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	prom "qbit-exp/prometheus"
+)
+
+func TestGetLog(t *testing.T) {
+	t.Parallel()
+
+	var lastKnownIDs []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastKnownID := r.URL.Query().Get("last_known_id")
+
+		switch r.URL.Path {
+		case "/api/v2/log/main":
+			lastKnownIDs = append(lastKnownIDs, lastKnownID)
+
+			if lastKnownID == "-1" {
+				_, _ = w.Write([]byte(`[{"id":0,"message":"started","type":1},{"id":1,"message":"I/O error","type":8}]`))
+			} else {
+				_, _ = w.Write([]byte(`[{"id":2,"message":"disk full","type":8}]`))
+			}
+		case "/api/v2/log/peers":
+			_, _ = w.Write([]byte(`[{"id":0,"ip":"1.1.1.1","blocked":true,"reason":"banned"}]`))
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+		}
+	}))
+	defer server.Close()
+
+	client := newFakeClient(server.URL)
+
+	client.getLog(prom.NewRegistry(nil))
+
+	registry := prom.NewRegistry(nil)
+	client.getLog(registry)
+
+	if strings.Join(lastKnownIDs, ",") != "-1,1" {
+		t.Errorf("expected only the new entries to be requested, got last_known_id %v", lastKnownIDs)
+	}
+
+	var output bytes.Buffer
+
+	registry.WritePrometheus(&output)
+
+	for _, expected := range []string{
+		`qbittorrent_log_entries_total{severity="critical"} 2`,
+		`qbittorrent_log_entries_total{severity="normal"} 1`,
+		`qbittorrent_log_banned_peers_total 1`,
+	} {
+		if !strings.Contains(output.String(), expected+"\n") {
+			t.Errorf("expected %s in\n%s", expected, output.String())
+		}
+	}
+}
+
+func TestGetLogRestart(t *testing.T) {
+	t.Parallel()
+
+	var lastKnownIDs []string
+
+	restarted := false
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastKnownID := r.URL.Query().Get("last_known_id")
+
+		switch r.URL.Path {
+		case "/api/v2/log/main":
+			lastKnownIDs = append(lastKnownIDs, lastKnownID)
+
+			switch {
+			case !restarted:
+				_, _ = w.Write([]byte(`[{"id":0,"message":"started","timestamp":100,"type":1},{"id":1,"message":"I/O error","timestamp":101,"type":8}]`))
+			case lastKnownID == "-1":
+				_, _ = w.Write([]byte(`[{"id":0,"message":"started","timestamp":200,"type":1},{"id":1,"message":"disk full","timestamp":201,"type":8},` +
+					`{"id":2,"message":"disk full","timestamp":202,"type":8}]`))
+			default:
+				_, _ = w.Write([]byte(`[{"id":1,"message":"disk full","timestamp":201,"type":8},{"id":2,"message":"disk full","timestamp":202,"type":8}]`))
+			}
+		case "/api/v2/log/peers":
+			_, _ = w.Write([]byte(`[]`))
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+		}
+	}))
+	defer server.Close()
+
+	client := newFakeClient(server.URL)
+
+	client.getLog(prom.NewRegistry(nil))
+
+	// qBittorrent restarted, the new log IDs are already past the last one seen
+	restarted = true
+
+	client.logState.CheckRestart()
+
+	registry := prom.NewRegistry(nil)
+	client.getLog(registry)
+
+	if strings.Join(lastKnownIDs, ",") != "-1,0,-1" {
+		t.Errorf("expected the last entry seen, then the whole log to be requested, got last_known_id %v", lastKnownIDs)
+	}
+
+	var output bytes.Buffer
+
+	registry.WritePrometheus(&output)
+
+	for _, expected := range []string{
+		`qbittorrent_log_entries_total{severity="critical"} 3`,
+		`qbittorrent_log_entries_total{severity="normal"} 2`,
+	} {
+		if !strings.Contains(output.String(), expected+"\n") {
+			t.Errorf("expected %s in\n%s", expected, output.String())
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package qbit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	API "qbit-exp/api"
+	"qbit-exp/app"
+	"qbit-exp/deltasync"
+	"qbit-exp/logger"
+	prom "qbit-exp/prometheus"
+)
+
+// getPeers collects the peers of the selected torrents, at most
+// PEERS_MAX_TORRENTS of them. Each torrent keeps its own delta sync state,
+// which is dropped once the torrent is not selected.
+func (c *Client) getPeers(torrents API.SliceInfo, r *prom.Registry) {
+	selected := selectTorrents(torrents, app.Exporter.Peers.Torrents, isTransferring)
+	selected = limitTorrents(selected, app.Exporter.Peers.MaxTorrents)
+
+	states := make(map[string]*deltasync.PeersState, len(selected))
+
+	for _, torrent := range selected {
+		state, exists := c.peersStates[torrent.Hash]
+		if !exists {
+			state = deltasync.NewPeersState()
+		}
+
+		states[torrent.Hash] = state
+	}
+
+	c.peersStates = states
+
+	results := make([]*prom.TorrentPeers, len(selected))
+
+	forEachTorrent(selected, func(i int, torrent API.Info) {
+		state := states[torrent.Hash]
+
+		err := c.fetchDeltaTorrentPeers(torrent.Hash, state)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Can't get the peers of %s: %s", torrent.Name, err))
+
+			return
+		}
+
+		results[i] = &prom.TorrentPeers{Torrent: torrent, Peers: state.GetPeers()}
+	})
+
+	torrentsPeers := make([]prom.TorrentPeers, 0, len(results))
+
+	for _, result := range results {
+		if result != nil {
+			torrentsPeers = append(torrentsPeers, *result)
+		}
+	}
+
+	prom.Peers(torrentsPeers, app.Exporter.Peers.MaxGroups, r)
+}
+
+// fetchDeltaTorrentPeers fetches sync/torrentPeers with rid parameter and
+// applies it to the peers state of the torrent.
+func (c *Client) fetchDeltaTorrentPeers(hash string, state *deltasync.PeersState) error {
+	url := c.createUrl(baseAPIRUL + "sync/torrentPeers")
+
+	queryParams := &[]QueryParams{
+		{Key: "hash", Value: hash},
+		{Key: "rid", Value: strconv.FormatInt(state.GetRID(), 10)},
+	}
+
+	body, retry, err := c.apiRequest(url, http.MethodGet, queryParams)
+	if retry {
+		logger.Debug("Retrying torrent peers request...")
+
+		body, _, err = c.apiRequest(url, http.MethodGet, queryParams)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	var delta API.DeltaTorrentPeers
+
+	err = json.Unmarshal(body, &delta)
+	if err != nil {
+		errMsg := fmt.Errorf("%s %s: %w", unmarshError, url, err)
+		errorHelper(&body, &errMsg, &url)
+
+		return err
+	}
+
+	state.Apply(&delta)
+
+	return nil
+}
+
+// isTransferring reports whether the torrent is downloading or uploading.
+func isTransferring(torrent API.Info) bool {
+	return torrent.Dlspeed > 0 || torrent.Upspeed > 0
+}
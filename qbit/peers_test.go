@@ -0,0 +1,89 @@
+package qbit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	API "qbit-exp/api"
+	"qbit-exp/app"
+	"qbit-exp/deltasync"
+	prom "qbit-exp/prometheus"
+)
+
+func TestFetchDeltaTorrentPeers(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/sync/torrentPeers" || r.URL.Query().Get("hash") != "hash1" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+
+		rid := r.URL.Query().Get("rid")
+		delta := API.DeltaTorrentPeers{Rid: 1, FullUpdate: true} //nolint:exhaustruct
+
+		if rid == "0" {
+			delta.Peers = map[string]json.RawMessage{"1.1.1.1:6881": json.RawMessage(`{"client":"qBittorrent/5.0.0","up_speed":10}`)}
+		} else {
+			delta = API.DeltaTorrentPeers{Rid: 2, PeersRemoved: []string{"1.1.1.1:6881"}} //nolint:exhaustruct
+		}
+
+		_ = json.NewEncoder(w).Encode(delta)
+	}))
+	defer server.Close()
+
+	client := newFakeClient(server.URL)
+	state := deltasync.NewPeersState()
+
+	if err := client.fetchDeltaTorrentPeers("hash1", state); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if peers := state.GetPeers(); len(peers) != 1 || peers[0].UpSpeed != 10 {
+		t.Errorf("expected 1 peer, got %+v", peers)
+	}
+
+	if err := client.fetchDeltaTorrentPeers("hash1", state); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if peers := state.GetPeers(); len(peers) != 0 || state.GetRID() != 2 {
+		t.Errorf("expected the delta to remove the peer, got %+v (rid %d)", peers, state.GetRID())
+	}
+}
+
+func TestGetPeersMaxTorrents(t *testing.T) {
+	previous := app.Exporter.Peers
+	app.Exporter.Peers = app.PeersSettings{Torrents: nil, MaxGroups: 50, MaxTorrents: 2}
+
+	defer func() { app.Exporter.Peers = previous }()
+
+	var requests atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+
+		_ = json.NewEncoder(w).Encode(API.DeltaTorrentPeers{Rid: 1, FullUpdate: true}) //nolint:exhaustruct
+	}))
+	defer server.Close()
+
+	client := newFakeClient(server.URL)
+	torrents := API.SliceInfo{
+		{Hash: "hash1", Upspeed: 10},   //nolint:exhaustruct
+		{Hash: "hash2", Dlspeed: 20},   //nolint:exhaustruct
+		{Hash: "hash3", Upspeed: 30},   //nolint:exhaustruct
+		{Hash: "idle", AmountLeft: 10}, //nolint:exhaustruct
+	}
+
+	client.getPeers(torrents, prom.NewRegistry(nil))
+
+	if requests.Load() != 2 {
+		t.Errorf("expected the peers of 2 torrents to be requested, got %d", requests.Load())
+	}
+
+	if _, exists := client.peersStates["hash1"]; exists || len(client.peersStates) != 2 {
+		t.Errorf("expected the states of the 2 fastest torrents, got %v", client.peersStates)
+	}
+}
@@ -0,0 +1,97 @@
+package qbit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"qbit-exp/logger"
+	prom "qbit-exp/prometheus"
+)
+
+var (
+	ErrNoSnapshot    = errors.New("no metrics collected yet")
+	ErrStaleSnapshot = errors.New("metrics are too old")
+)
+
+// Poller collects the metrics in the background, so that scrapes are served
+// from the latest snapshot instead of querying qBittorrent.
+type Poller struct {
+	interval time.Duration
+	maxAge   time.Duration
+	collect  func(r *prom.Registry) error
+
+	mu          sync.RWMutex
+	snapshot    *prom.Registry
+	collectedAt time.Time
+}
+
+// NewPoller returns a poller calling collect every interval. Snapshots older
+// than maxAge are not served.
+func NewPoller(interval time.Duration, maxAge time.Duration, collect func(r *prom.Registry) error) *Poller {
+	return &Poller{
+		interval:    interval,
+		maxAge:      maxAge,
+		collect:     collect,
+		mu:          sync.RWMutex{},
+		snapshot:    nil,
+		collectedAt: time.Time{},
+	}
+}
+
+// Run collects the metrics until ctx is done.
+func (p *Poller) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.poll()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll collects the metrics and replaces the snapshot. On error, the previous
+// snapshot is kept until it is too old.
+func (p *Poller) poll() {
+	start := time.Now()
+	r := prom.NewRegistry(nil)
+
+	if err := p.collect(r); err != nil {
+		logger.Error(fmt.Sprintf("Can't collect the metrics in the background: %s", err))
+
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.snapshot = r
+	p.collectedAt = start
+}
+
+// Collect includes the latest snapshot in r, with its age.
+func (p *Poller) Collect(r *prom.Registry) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.snapshot == nil {
+		return ErrNoSnapshot
+	}
+
+	age := time.Since(p.collectedAt)
+	if p.maxAge > 0 && age > p.maxAge {
+		return fmt.Errorf("%w (collected %s ago)", ErrStaleSnapshot, age.Round(time.Second))
+	}
+
+	r.Include(p.snapshot)
+	prom.SnapshotAge(r, age)
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package qbit
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	prom "qbit-exp/prometheus"
+)
+
+func newTestPoller(collect func(r *prom.Registry) error) *Poller {
+	return NewPoller(time.Minute, 3*time.Minute, collect)
+}
+
+func TestPollerNoSnapshot(t *testing.T) {
+	t.Parallel()
+
+	poller := newTestPoller(func(_ *prom.Registry) error {
+		return errors.New("mock error")
+	})
+	poller.poll()
+
+	if err := poller.Collect(prom.NewRegistry(nil)); !errors.Is(err, ErrNoSnapshot) {
+		t.Errorf("expected ErrNoSnapshot, got %v", err)
+	}
+}
+
+func TestPollerServesSnapshot(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+
+	poller := newTestPoller(func(r *prom.Registry) error {
+		calls++
+
+		r.GetOrCreateGauge("qbittorrent_global_torrents", nil).Set(3)
+
+		return nil
+	})
+	poller.poll()
+
+	for range 2 {
+		r := prom.NewRegistry(nil)
+		if err := poller.Collect(r); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var output bytes.Buffer
+
+		r.WritePrometheus(&output)
+
+		for _, expected := range []string{"qbittorrent_global_torrents 3\n", "qbittorrent_exporter_snapshot_age_seconds "} {
+			if !strings.Contains(output.String(), expected) {
+				t.Errorf("expected %s in\n%s", expected, output.String())
+			}
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("expected scrapes to be served from the snapshot, got %d collections", calls)
+	}
+}
+
+func TestPollerKeepsSnapshotOnError(t *testing.T) {
+	t.Parallel()
+
+	var err error
+
+	poller := newTestPoller(func(r *prom.Registry) error {
+		r.GetOrCreateGauge("qbittorrent_global_torrents", nil).Set(1)
+
+		return err
+	})
+	poller.poll()
+
+	err = errors.New("mock error")
+	poller.poll()
+
+	if err := poller.Collect(prom.NewRegistry(nil)); err != nil {
+		t.Errorf("expected the previous snapshot to be served, got %v", err)
+	}
+}
+
+func TestPollerStaleSnapshot(t *testing.T) {
+	t.Parallel()
+
+	poller := newTestPoller(func(_ *prom.Registry) error {
+		return nil
+	})
+	poller.poll()
+
+	poller.collectedAt = time.Now().Add(-4 * time.Minute)
+
+	if err := poller.Collect(prom.NewRegistry(nil)); !errors.Is(err, ErrStaleSnapshot) {
+		t.Errorf("expected ErrStaleSnapshot, got %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package qbit
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"qbit-exp/app"
+	"qbit-exp/internal"
+	"qbit-exp/logger"
+	prom "qbit-exp/prometheus"
+)
+
+var (
+	ErrInvalidTarget    = errors.New("invalid target")
+	ErrUnknownModule    = errors.New("unknown module")
+	ErrTargetNotAllowed = errors.New("target not allowed")
+)
+
+// probeClientTTL is the duration after which the client of a target that is
+// no longer probed is dropped, along with its delta sync state.
+const probeClientTTL = time.Hour
+
+// maxProbeClients is the max number of cached clients. When it is reached,
+// the least recently used client is dropped.
+const maxProbeClients = 100
+
+type probeClient struct {
+	client   *Client
+	lastUsed time.Time
+}
+
+var (
+	probeClientsMu sync.Mutex
+	probeClients   = make(map[string]*probeClient)
+)
+
+// Probe collects the metrics of target using the credentials of module. The
+// target must be one of the targets of the module. Clients are cached by
+// module and target so that the delta sync state persists between probes.
+func Probe(target string, module string, r *prom.Registry) error {
+	baseUrl, err := probeTargetUrl(target)
+	if err != nil {
+		return err
+	}
+
+	settings, exists := app.ProbeModules[module]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrUnknownModule, module)
+	}
+
+	if !slices.Contains(settings.ProbeTargets, baseUrl) {
+		return fmt.Errorf("%w: %s", ErrTargetNotAllowed, baseUrl)
+	}
+
+	client := getProbeClient(baseUrl, module, settings)
+	registry := prom.NewRegistry(client.labels)
+
+	err = reportUp(registry, client.AllRequests(registry))
+	r.Include(registry)
+
+	return err
+}
+
+// probeTargetUrl returns the base URL of target, which can omit the scheme.
+func probeTargetUrl(target string) (string, error) {
+	if target == "" {
+		return "", fmt.Errorf("%w: target is empty", ErrInvalidTarget)
+	}
+
+	baseUrl, valid := internal.NormalizeBaseURL(target)
+	if !valid {
+		return "", fmt.Errorf("%w: %s", ErrInvalidTarget, baseUrl)
+	}
+
+	return baseUrl, nil
+}
+
+func getProbeClient(baseUrl string, module string, settings *app.QBittorrentSettings) *Client {
+	probeClientsMu.Lock()
+	defer probeClientsMu.Unlock()
+
+	now := time.Now()
+
+	for key, cached := range probeClients {
+		if now.Sub(cached.lastUsed) > probeClientTTL {
+			logger.Debug("Dropping probe client for " + cached.client.settings.BaseUrl)
+			delete(probeClients, key)
+		}
+	}
+
+	key := module + "\x00" + baseUrl
+
+	cached, exists := probeClients[key]
+	if !exists {
+		if len(probeClients) >= maxProbeClients {
+			dropLeastRecentlyUsedProbeClient()
+		}
+
+		targetSettings := *settings
+		targetSettings.BaseUrl = baseUrl
+
+		// Each target gets its own session cookie
+		if settings.LegacyAuth != nil {
+			legacyAuth := *settings.LegacyAuth
+			legacyAuth.Cookie.Value = nil
+			targetSettings.LegacyAuth = &legacyAuth
+		}
+
+		client := NewClient(&targetSettings)
+		// The probe modules are not instances, the series are labeled with the target
+		client.labels = instanceLabels("", baseUrl)
+
+		cached = &probeClient{client: client, lastUsed: now}
+		probeClients[key] = cached
+	}
+
+	cached.lastUsed = now
+
+	return cached.client
+}
+
+// ResetProbeClients drops the cached clients, so that the next probes use the
+// current credentials of the modules.
+func ResetProbeClients() {
+	probeClientsMu.Lock()
+	defer probeClientsMu.Unlock()
+
+	clear(probeClients)
+}
+
+// dropLeastRecentlyUsedProbeClient drops the client probed the longest time
+// ago. probeClientsMu must be held.
+func dropLeastRecentlyUsedProbeClient() {
+	var oldestKey string
+
+	var oldest *probeClient
+
+	for key, cached := range probeClients {
+		if oldest == nil || cached.lastUsed.Before(oldest.lastUsed) {
+			oldestKey, oldest = key, cached
+		}
+	}
+
+	if oldest != nil {
+		logger.Debug("Dropping probe client for " + oldest.client.settings.BaseUrl)
+		delete(probeClients, oldestKey)
+	}
+}
@@ -0,0 +1,145 @@
+package qbit
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"qbit-exp/app"
+	prom "qbit-exp/prometheus"
+)
+
+func TestProbeTargetUrl(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		target   string
+		expected string
+		wantErr  bool
+	}{
+		{"Full URL", "https://192.168.1.10:8080/", "https://192.168.1.10:8080", false},
+		{"Host and port", "192.168.1.10:8080", "http://192.168.1.10:8080", false},
+		{"Empty target", "", "", true},
+		{"Invalid URL", "http://", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := probeTargetUrl(tt.target)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidTarget) {
+					t.Errorf("expected ErrInvalidTarget, got %v", err)
+				}
+
+				return
+			}
+
+			if err != nil || got != tt.expected {
+				t.Errorf("probeTargetUrl(%q) = %q, %v, want %q", tt.target, got, err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestProbeUnknownModule(t *testing.T) {
+	app.ProbeModules = map[string]*app.QBittorrentSettings{"": &app.QBittorrent}
+
+	err := Probe("localhost:8080", "unknown", prom.NewRegistry(nil))
+	if !errors.Is(err, ErrUnknownModule) {
+		t.Errorf("expected ErrUnknownModule, got %v", err)
+	}
+}
+
+func TestProbeTargetNotAllowed(t *testing.T) {
+	module := &app.QBittorrentSettings{ProbeTargets: []string{"http://allowed:8080"}} //nolint:exhaustruct
+	app.ProbeModules = map[string]*app.QBittorrentSettings{"": module}
+
+	err := Probe("attacker.example:8080", "", prom.NewRegistry(nil))
+	if !errors.Is(err, ErrTargetNotAllowed) {
+		t.Errorf("expected ErrTargetNotAllowed, got %v", err)
+	}
+}
+
+func TestGetProbeClientMaxClients(t *testing.T) {
+	settings := &app.QBittorrentSettings{BaseUrl: "http://module"} //nolint:exhaustruct
+
+	first := getProbeClient("http://first", "max", settings)
+
+	for i := range maxProbeClients {
+		getProbeClient(fmt.Sprintf("http://target%d", i), "max", settings)
+	}
+
+	probeClientsMu.Lock()
+	count := len(probeClients)
+	probeClientsMu.Unlock()
+
+	if count > maxProbeClients {
+		t.Errorf("expected at most %d clients, got %d", maxProbeClients, count)
+	}
+
+	if getProbeClient("http://first", "max", settings) == first {
+		t.Error("expected the least recently used client to be dropped")
+	}
+}
+
+func TestGetProbeClient(t *testing.T) {
+	cookie := "cookie"
+	settings := &app.QBittorrentSettings{ //nolint:exhaustruct
+		BaseUrl: "http://module",
+		LegacyAuth: &app.LegacyAuth{
+			Username: "admin",
+			Password: "adminadmin",
+			Cookie:   app.Cookie{Key: "SID", Value: &cookie},
+		},
+	}
+
+	client := getProbeClient("http://target1", "module", settings)
+	if client.settings.BaseUrl != "http://target1" {
+		t.Errorf("expected base URL http://target1, got %s", client.settings.BaseUrl)
+	}
+
+	if client.settings.LegacyAuth.Cookie.Value != nil {
+		t.Error("expected the target to get its own cookie")
+	}
+
+	if settings.LegacyAuth.Cookie.Value != &cookie || settings.BaseUrl != "http://module" {
+		t.Error("expected the module settings to be left untouched")
+	}
+
+	if getProbeClient("http://target1", "module", settings) != client {
+		t.Error("expected the client to be cached")
+	}
+
+	if getProbeClient("http://target2", "module", settings) == client {
+		t.Error("expected a new client for another target")
+	}
+
+	probeClientsMu.Lock()
+	for _, cached := range probeClients {
+		cached.lastUsed = time.Now().Add(-2 * probeClientTTL)
+	}
+	probeClientsMu.Unlock()
+
+	if getProbeClient("http://target1", "module", settings) == client {
+		t.Error("expected the expired client to be dropped")
+	}
+}
+
+func TestResetProbeClients(t *testing.T) {
+	settings := &app.QBittorrentSettings{BaseUrl: "http://module"} //nolint:exhaustruct
+
+	client := getProbeClient("http://target1", "reset", settings)
+
+	ResetProbeClients()
+
+	reloaded := &app.QBittorrentSettings{BaseUrl: "http://module", Timeout: time.Second} //nolint:exhaustruct
+
+	got := getProbeClient("http://target1", "reset", reloaded)
+	if got == client || got.settings.Timeout != time.Second {
+		t.Error("expected a new client with the reloaded settings")
+	}
+}
@@ -0,0 +1,75 @@
+package qbit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	API "qbit-exp/api"
+	"qbit-exp/app"
+	"qbit-exp/logger"
+	prom "qbit-exp/prometheus"
+)
+
+// getProperties collects the properties of the selected torrents, at most
+// PROPERTIES_MAX_TORRENTS of them.
+func (c *Client) getProperties(torrents API.SliceInfo, r *prom.Registry) {
+	selected := selectTorrents(torrents, app.Exporter.Properties.Torrents, func(API.Info) bool { return true })
+	selected = limitTorrents(selected, app.Exporter.Properties.MaxTorrents)
+
+	results := make([]*prom.TorrentProperties, len(selected))
+
+	forEachTorrent(selected, func(i int, torrent API.Info) {
+		properties, err := c.fetchTorrentProperties(torrent.Hash)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Can't get the properties of %s: %s", torrent.Name, err))
+
+			return
+		}
+
+		results[i] = &prom.TorrentProperties{Torrent: torrent, Properties: *properties}
+	})
+
+	torrentsProperties := make([]prom.TorrentProperties, 0, len(results))
+
+	for _, result := range results {
+		if result != nil {
+			torrentsProperties = append(torrentsProperties, *result)
+		}
+	}
+
+	prom.Properties(torrentsProperties, r)
+}
+
+// fetchTorrentProperties fetches the properties of a torrent from
+// torrents/properties.
+func (c *Client) fetchTorrentProperties(hash string) (*API.Properties, error) {
+	url := c.createUrl(baseAPIRUL + "torrents/properties")
+
+	queryParams := &[]QueryParams{
+		{Key: "hash", Value: hash},
+	}
+
+	body, retry, err := c.apiRequest(url, http.MethodGet, queryParams)
+	if retry {
+		logger.Debug("Retrying torrent properties request...")
+
+		body, _, err = c.apiRequest(url, http.MethodGet, queryParams)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	properties := new(API.Properties)
+
+	err = json.Unmarshal(body, properties)
+	if err != nil {
+		errMsg := fmt.Errorf("%s %s: %w", unmarshError, url, err)
+		errorHelper(&body, &errMsg, &url)
+
+		return nil, err
+	}
+
+	return properties, nil
+}
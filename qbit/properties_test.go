@@ -0,0 +1,33 @@
+package qbit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFetchTorrentProperties(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/torrents/properties" || r.URL.Query().Get("hash") != "hash1" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+
+		_, _ = w.Write([]byte(`{"piece_size":4194304,"pieces_have":10,"pieces_num":20,"nb_connections":3,"reannounce":120}`))
+	}))
+	defer server.Close()
+
+	properties, err := newFakeClient(server.URL).fetchTorrentProperties("hash1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if properties.PieceSize != 4194304 || properties.PiecesHave != 10 || properties.PiecesNum != 20 || properties.Reannounce != 120 {
+		t.Errorf("unexpected properties %+v", properties)
+	}
+
+	if properties.RatioLimit != nil || properties.SeedingTimeLimit != nil {
+		t.Errorf("expected no share limits when not sent, got %+v", properties)
+	}
+}
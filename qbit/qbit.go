@@ -7,24 +7,124 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strconv"
 	"sync"
+	"time"
 
 	API "qbit-exp/api"
 	"qbit-exp/app"
 	"qbit-exp/deltasync"
 	"qbit-exp/logger"
 	prom "qbit-exp/prometheus"
-
-	"github.com/VictoriaMetrics/metrics"
 )
 
-// syncState holds the persistent state for delta sync.
-// Initialized on first scrape, persists between scrapes.
-var syncState *deltasync.State
+// Client scrapes a single qBittorrent instance. Concurrent scrapes of the
+// same instance share a single collection, so that the delta sync state is
+// only updated by one of them.
+type Client struct {
+	settings *app.QBittorrentSettings
+
+	// baseUrl is the URL the sync state comes from. The settings of the
+	// default instance are replaced in place on reload.
+	baseUrl string
+
+	// labels are added to every series of the instance, see instanceLabels.
+	labels map[string]string
+
+	// syncState holds the persistent state for delta sync.
+	// Persists between scrapes.
+	syncState *deltasync.State
+
+	// scrapeCount tracks number of scrapes for periodic full refresh.
+	scrapeCount int64
+
+	// peersStates holds the delta sync state of the peers of each torrent
+	// whose peers are collected, by hash.
+	peersStates map[string]*deltasync.PeersState
+
+	// logState holds the last log entries seen, persists between scrapes.
+	logState *deltasync.LogState
+
+	// stats holds the statistics of the collections, persists between
+	// scrapes.
+	stats scrapeStats
+
+	// mu guards inFlight, the collection shared by concurrent scrapes.
+	mu       sync.Mutex
+	inFlight *collection
+
+	// cookieMu guards the session cookie, renewed while requests run.
+	cookieMu sync.RWMutex
+}
+
+// collection is the result of a collection, available once done is closed.
+type collection struct {
+	done     chan struct{}
+	registry *prom.Registry
+	stats    *prom.ScrapeStats
+	err      error
+}
+
+var errCollectionAborted = errors.New("collection aborted")
+
+// clients holds one client per configured qBittorrent instance.
+var clients []*Client
+
+// NewClient creates a client for the qBittorrent instance described by settings.
+func NewClient(settings *app.QBittorrentSettings) *Client {
+	return &Client{
+		settings:    settings,
+		baseUrl:     settings.BaseUrl,
+		labels:      instanceLabels(settings.Name, settings.BaseUrl),
+		syncState:   deltasync.NewState(),
+		scrapeCount: 0,
+		peersStates: make(map[string]*deltasync.PeersState),
+		logState:    deltasync.NewLogState(),
+		stats:       scrapeStats{}, //nolint:exhaustruct
+		mu:          sync.Mutex{},
+		inFlight:    nil,
+		cookieMu:    sync.RWMutex{},
+	}
+}
+
+// SetInstances sets the clients used by AllRequests, one per instance. On
+// reload, the clients of the instances keeping their name and URL are kept,
+// with their sync state, and use the new settings.
+func SetInstances(instances []*app.QBittorrentSettings) {
+	previous := make(map[string]*Client, len(clients))
+	for _, client := range clients {
+		previous[client.settings.Name] = client
+	}
+
+	clients = make([]*Client, 0, len(instances))
+
+	for _, instance := range instances {
+		client, exists := previous[instance.Name]
+		if exists && client.baseUrl == instance.BaseUrl {
+			client.settings = instance
+		} else {
+			client = NewClient(instance)
+		}
+
+		clients = append(clients, client)
+	}
+}
+
+// instanceLabels returns the labels of the series of an instance: its name, or
+// the host of its URL for the instance without name.
+func instanceLabels(name string, baseUrl string) map[string]string {
+	if name == "" {
+		name = baseUrl
+
+		u, err := url.Parse(baseUrl)
+		if err == nil && u.Host != "" {
+			name = u.Host
+		}
+	}
 
-// scrapeCount tracks number of scrapes for periodic full refresh.
-var scrapeCount int64
+	return map[string]string{prom.LabelInstance: name}
+}
 
 type QueryParams struct {
 	Key   string
@@ -36,7 +136,7 @@ type Data struct {
 	URL         string
 	HTTPMethod  string
 	QueryParams *[]QueryParams
-	Handle      func(body []byte, r *metrics.Set, webUIVersion *string) error
+	Handle      func(body []byte, r *prom.Registry, webUIVersion *string) error
 }
 
 type UniqueTracker struct {
@@ -48,7 +148,7 @@ const unmarshError string = "Can not unmarshal JSON for"
 
 const baseAPIRUL string = "/api/v2/"
 
-func newData(url string, handler func(body []byte, r *metrics.Set, webUIVersion *string) error) Data {
+func newData(url string, handler func(body []byte, r *prom.Registry, webUIVersion *string) error) Data {
 	return Data{
 		Process:     &url,
 		URL:         baseAPIRUL + url,
@@ -63,12 +163,12 @@ var firstAPIRequest = newData("app/webapiVersion", nil)
 // staticAPIRequests are requests that don't benefit from delta sync.
 // These are small responses that change rarely.
 var staticAPIRequests = [...]Data{
-	newData("app/version", func(body []byte, r *metrics.Set, _ *string) error {
+	newData("app/version", func(body []byte, r *prom.Registry, _ *string) error {
 		prom.Version(&body, r)
 
 		return nil
 	}),
-	newData("app/preferences", func(body []byte, r *metrics.Set, _ *string) error {
+	newData("app/preferences", func(body []byte, r *prom.Registry, _ *string) error {
 		result := new(API.Preferences)
 
 		err := json.Unmarshal(body, result)
@@ -82,22 +182,22 @@ var staticAPIRequests = [...]Data{
 	}),
 }
 
-func createUrl(url string) string {
-	return app.QBittorrent.BaseUrl + url
+func (c *Client) createUrl(url string) string {
+	return c.settings.BaseUrl + url
 }
 
-func getData(r *metrics.Set, data *Data, webUIVersion *string, c chan func() (bool, error)) {
-	url := createUrl(data.URL)
+func (c *Client) getData(r *prom.Registry, data *Data, webUIVersion *string, ch chan func() (bool, error)) {
+	url := c.createUrl(data.URL)
 
-	body, retry, err := apiRequest(url, data.HTTPMethod, data.QueryParams)
+	body, retry, err := c.apiRequest(url, data.HTTPMethod, data.QueryParams)
 	if retry {
-		c <- func() (bool, error) { return true, nil }
+		ch <- func() (bool, error) { return true, nil }
 
 		return
 	}
 
 	if err != nil {
-		c <- func() (bool, error) { return false, err }
+		ch <- func() (bool, error) { return false, err }
 
 		return
 	}
@@ -107,20 +207,20 @@ func getData(r *metrics.Set, data *Data, webUIVersion *string, c chan func() (bo
 		errormessage := fmt.Errorf("%s %s: %w", unmarshError, url, err)
 		errorHelper(&body, &errormessage, &url)
 
-		c <- func() (bool, error) { return false, err }
+		ch <- func() (bool, error) { return false, err }
 
 		return
 	}
 
-	c <- func() (bool, error) { return false, nil }
+	ch <- func() (bool, error) { return false, nil }
 }
 
-func getTrackersInfo(data *Data, c chan func() (*API.Trackers, error)) {
-	url := createUrl(data.URL)
+func (c *Client) getTrackersInfo(data *Data, ch chan func() (*API.Trackers, error)) {
+	url := c.createUrl(data.URL)
 
-	body, _, err := apiRequest(url, data.HTTPMethod, data.QueryParams)
+	body, _, err := c.apiRequest(url, data.HTTPMethod, data.QueryParams)
 	if err != nil {
-		c <- (func() (*API.Trackers, error) { return nil, err })
+		ch <- (func() (*API.Trackers, error) { return nil, err })
 
 		return
 	}
@@ -137,15 +237,15 @@ func getTrackersInfo(data *Data, c chan func() (*API.Trackers, error)) {
 		errMsg := fmt.Errorf("%s %s", unmarshError, process)
 		errorHelper(&body, &errMsg, &url)
 
-		c <- (func() (*API.Trackers, error) { return nil, errMsg })
+		ch <- (func() (*API.Trackers, error) { return nil, errMsg })
 
 		return
 	}
 
-	c <- (func() (*API.Trackers, error) { return result, nil })
+	ch <- (func() (*API.Trackers, error) { return result, nil })
 }
 
-func getTrackers(torrentList *API.SliceInfo, r *metrics.Set) {
+func (c *Client) getTrackers(torrentList *API.SliceInfo, r *prom.Registry) {
 	var wg sync.WaitGroup
 
 	uniqueValues := make(map[string]struct{})
@@ -166,7 +266,7 @@ func getTrackers(torrentList *API.SliceInfo, r *metrics.Set) {
 	processData := func(trackerInfo *Data) {
 		defer wg.Done()
 
-		getTrackersInfo(trackerInfo, tracker)
+		c.getTrackersInfo(trackerInfo, tracker)
 	}
 
 	for i := range uniqueTrackers {
@@ -205,16 +305,133 @@ func getTrackers(torrentList *API.SliceInfo, r *metrics.Set) {
 	prom.Trackers(*responses, r)
 }
 
-func AllRequests(r *metrics.Set) error {
+// AllRequests collects the metrics of every instance. Series are labeled with
+// the instance, see instanceLabels. It only fails when no instance could be
+// scraped, and none of them is down.
+func AllRequests(r *prom.Registry) error {
+	if len(clients) == 0 {
+		return errors.New("no qBittorrent instance configured")
+	}
+
+	var wg sync.WaitGroup
+
+	registries := make([]*prom.Registry, len(clients))
+	errs := make([]error, len(clients))
+
+	for i, client := range clients {
+		registries[i] = prom.NewRegistry(client.labels)
+
+		wg.Go(func() {
+			errs[i] = client.AllRequests(registries[i])
+		})
+	}
+
+	wg.Wait()
+
+	for i, client := range clients {
+		if errs[i] != nil {
+			logger.Error(fmt.Sprintf("Can't scrape instance %s: %s", client.labels[prom.LabelInstance], errs[i]))
+		}
+
+		errs[i] = reportUp(registries[i], errs[i])
+		r.Include(registries[i])
+	}
+
+	for _, err := range errs {
+		if err == nil {
+			return nil
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// IsDown reports whether err means that qBittorrent is unreachable or refuses
+// the credentials of the exporter. Scrapes then report qbittorrent_up 0.
+func IsDown(err error) bool {
+	var unreachableError *API.UnreachableError
+
+	return errors.As(err, &unreachableError) || IsAuthFailure(err)
+}
+
+// reportUp sets qbittorrent_up from the error of a collection. The errors for
+// which IsDown is true are only reported by qbittorrent_up 0.
+func reportUp(r *prom.Registry, err error) error {
+	prom.Up(r, err == nil)
+
+	if IsDown(err) {
+		return nil
+	}
+
+	return err
+}
+
+// AllRequests collects the metrics of the instance into r. When a collection
+// is already running, it waits for its result instead of starting a new one.
+func (c *Client) AllRequests(r *prom.Registry) error {
+	c.mu.Lock()
+
+	current := c.inFlight
+	if current == nil {
+		current = &collection{
+			done:     make(chan struct{}),
+			registry: prom.NewRelabeledRegistry(c.labels, app.Exporter.Relabel, c.syncState.GetTorrents),
+			stats:    nil,
+			err:      errCollectionAborted,
+		}
+		c.inFlight = current
+
+		c.mu.Unlock()
+		c.runCollection(current)
+	} else {
+		c.mu.Unlock()
+		logger.Trace("Waiting for the collection in progress")
+		<-current.done
+	}
+
+	if current.stats != nil {
+		prom.Scrape(current.stats, r)
+	}
+
+	if current.err != nil {
+		return current.err
+	}
+
+	r.Include(current.registry)
+
+	return nil
+}
+
+func (c *Client) runCollection(current *collection) {
+	defer func() {
+		c.mu.Lock()
+		c.inFlight = nil
+		c.mu.Unlock()
+
+		close(current.done)
+	}()
+
+	start := time.Now()
+
+	c.stats.startCollection()
+	current.err = c.collect(current.registry)
+	c.stats.endCollection(start, current.err == nil)
+
+	current.stats = c.stats.get(c.syncState.TorrentCount())
+}
+
+// collect queries the instance and registers its metrics into r. It must not
+// run concurrently, since it updates the delta sync state.
+func (c *Client) collect(r *prom.Registry) error {
 	var wg sync.WaitGroup
 
-	firstRequestUrl := createUrl(firstAPIRequest.URL)
+	firstRequestUrl := c.createUrl(firstAPIRequest.URL)
 
-	webUIVersionBytes, retry, err := apiRequest(firstRequestUrl, firstAPIRequest.HTTPMethod, firstAPIRequest.QueryParams)
+	webUIVersionBytes, retry, err := c.apiRequest(firstRequestUrl, firstAPIRequest.HTTPMethod, firstAPIRequest.QueryParams)
 	if retry {
 		logger.Debug("Retrying ...")
 
-		webUIVersionBytes, _, err = apiRequest(firstRequestUrl, firstAPIRequest.HTTPMethod, firstAPIRequest.QueryParams)
+		webUIVersionBytes, _, err = c.apiRequest(firstRequestUrl, firstAPIRequest.HTTPMethod, firstAPIRequest.QueryParams)
 	}
 
 	webUIVersion := string(webUIVersionBytes)
@@ -224,41 +441,91 @@ func AllRequests(r *metrics.Set) error {
 		return err
 	}
 
-	// Initialize sync state if needed
-	if syncState == nil {
-		syncState = deltasync.NewState()
-	}
-
 	// Periodic full refresh to prevent state drift
-	scrapeCount++
-	if scrapeCount%int64(app.QBittorrent.FullRefreshInterval) == 0 {
+	c.scrapeCount++
+	if c.scrapeCount%int64(c.settings.FullRefreshInterval) == 0 {
 		logger.Debug("Forcing full sync for state drift prevention")
-		syncState.Reset()
+		c.syncState.Reset()
 	}
 
 	// Fetch delta maindata (replaces both torrents/info and sync/maindata)
-	deltaErr := fetchDeltaMainData()
+	deltaErr := c.fetchDeltaMainData()
 	if deltaErr != nil {
 		return deltaErr
 	}
 
 	// Get data from sync state for prometheus metrics
-	torrents := syncState.GetTorrents()
-	mainData := syncState.GetMainData()
+	torrents := c.syncState.GetTorrents()
+	mainData := c.syncState.GetMainData()
+
+	// Complete the server state with transfer/info
+	c.getTransferInfo(&mainData.ServerState, webUIVersion, r)
+
+	// Only the filtered torrents get per-torrent series
+	exported := filterTorrents(torrents, mainData.Trackers, &app.Exporter.TorrentFilter)
+
+	// In the top torrents mode, the others are summed in a single series
+	var others API.SliceInfo
+	if app.Exporter.TopTorrents.Count > 0 {
+		exported, others = topTorrents(exported, &app.Exporter.TopTorrents)
+	}
 
 	// Register torrent metrics
-	prom.Torrent(&torrents, &webUIVersion, r)
+	prom.Torrent(&torrents, isExported(torrents, exported), &webUIVersion, r)
+
+	if app.Exporter.TopTorrents.Count > 0 {
+		prom.OtherTorrents(others, r)
+	}
 
 	// Register maindata metrics (categories, tags, server state)
 	prom.MainData(&mainData, r)
 
 	// Fetch tracker info if enabled
+	var trackerStatuses []*API.Trackers
+
 	if app.Exporter.Features.EnableTracker {
-		getTrackers(&torrents, r)
+		if app.Exporter.Features.EnableTrackerTorrents {
+			trackerStatuses = c.getTorrentsTrackers(torrents, r)
+		} else {
+			c.getTrackers(&torrents, r)
+		}
+	}
+
+	// Register the per-category, per-tag and per-tracker host aggregates if
+	// enabled, the torrents not working by tracker host need the trackers of
+	// every torrent
+	if app.Exporter.Features.EnableAggregates {
+		prom.Aggregates(torrents, &mainData, r)
+		prom.TrackerHosts(torrents, mainData.Trackers, trackerStatuses, r)
+	}
+
+	// Fetch the peers of the selected torrents if enabled
+	if app.Exporter.Features.EnablePeers {
+		c.getPeers(exported, r)
+	}
+
+	// Fetch the files of the selected torrents if enabled
+	if app.Exporter.Features.EnableFiles {
+		c.getFiles(exported, r)
+	}
+
+	// Fetch the properties of the selected torrents if enabled
+	if app.Exporter.Features.EnableProperties {
+		c.getProperties(exported, r)
+	}
+
+	// Fetch the new log entries if enabled
+	if app.Exporter.Features.EnableLog {
+		c.getLog(r)
+	}
+
+	// Fetch the RSS feeds and rules if enabled
+	if app.Exporter.Features.EnableRSS {
+		c.getRSS(r)
 	}
 
 	// Fetch static requests in parallel (app/version, app/preferences)
-	c := make(chan func() (bool, error), len(staticAPIRequests))
+	ch := make(chan func() (bool, error), len(staticAPIRequests))
 	processData := func(data *Data) {
 		defer wg.Done()
 		defer func() {
@@ -267,7 +534,7 @@ func AllRequests(r *metrics.Set) error {
 			}
 		}()
 
-		getData(r, data, &webUIVersion, c)
+		c.getData(r, data, &webUIVersion, ch)
 	}
 
 	for _, request := range staticAPIRequests {
@@ -278,10 +545,10 @@ func AllRequests(r *metrics.Set) error {
 
 	go func() {
 		wg.Wait()
-		close(c)
+		close(ch)
 	}()
 
-	for respFunc := range c {
+	for respFunc := range ch {
 		_, err := respFunc()
 		if err != nil {
 			return err
@@ -292,19 +559,19 @@ func AllRequests(r *metrics.Set) error {
 }
 
 // fetchDeltaMainData fetches sync/maindata with rid parameter and applies to state.
-func fetchDeltaMainData() error {
-	rid := syncState.GetRID()
-	url := createUrl(baseAPIRUL + "sync/maindata")
+func (c *Client) fetchDeltaMainData() error {
+	rid := c.syncState.GetRID()
+	url := c.createUrl(baseAPIRUL + "sync/maindata")
 
 	queryParams := &[]QueryParams{
 		{Key: "rid", Value: strconv.FormatInt(rid, 10)},
 	}
 
-	body, retry, err := apiRequest(url, http.MethodGet, queryParams)
+	body, retry, err := c.apiRequest(url, http.MethodGet, queryParams)
 	if retry {
 		logger.Debug("Retrying delta maindata request...")
 
-		body, _, err = apiRequest(url, http.MethodGet, queryParams)
+		body, _, err = c.apiRequest(url, http.MethodGet, queryParams)
 	}
 
 	if err != nil {
@@ -321,16 +588,21 @@ func fetchDeltaMainData() error {
 		return err
 	}
 
+	c.stats.observeSync(delta.FullUpdate || rid == 0)
+
 	// Log sync mode for debugging
 	if delta.FullUpdate || rid == 0 {
 		logger.Debug(fmt.Sprintf("Full sync: %d torrents", len(delta.Torrents)))
+
+		// qBittorrent may have restarted and started its logs again
+		c.logState.CheckRestart()
 	} else {
 		logger.Trace(fmt.Sprintf("Delta sync: %d torrent updates, %d removed",
 			len(delta.Torrents), len(delta.TorrentsRemoved)))
 	}
 
 	// Apply delta to state
-	syncState.Apply(&delta)
+	c.syncState.Apply(&delta)
 
 	return nil
 }
@@ -344,22 +616,36 @@ func errorHelper(body *[]byte, errMsg *error, url *string) {
 // - body (content of the http response)
 // - retry (if it should retry that query)
 // - err (the error if there was one during the request).
-func apiRequest(url string, method string, queryParams *[]QueryParams) ([]byte, bool, error) {
-	if app.QBittorrent.LegacyAuth.Cookie.Value == nil && app.QBittorrent.APIKey == nil {
+func (c *Client) apiRequest(url string, method string, queryParams *[]QueryParams) ([]byte, bool, error) {
+	cookie := c.cookie()
+
+	if cookie == nil && c.settings.APIKey == nil {
 		logger.Debug("no cookie set")
 
-		err := Auth()
+		err := c.Auth()
 		if err != nil {
 			return nil, false, err
 		}
+
+		cookie = c.cookie()
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), app.QBittorrent.Timeout)
+	start := time.Now()
+	body, retry, err := c.sendRequest(url, method, queryParams, cookie)
+	c.stats.observeRequest(apiEndpoint(url), time.Since(start), err != nil)
+
+	return body, retry, err
+}
+
+// sendRequest sends an API request, authenticated with the API key or with
+// the session cookie.
+func (c *Client) sendRequest(url string, method string, queryParams *[]QueryParams, cookie *string) ([]byte, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.settings.Timeout)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, method, url, nil)
 	if err != nil {
-		panic(fmt.Sprintf("%s %s", API.ErrorWithUrl, err.Error()))
+		return nil, false, fmt.Errorf("%s %w", API.ErrorWithUrl, err)
 	}
 
 	if queryParams != nil {
@@ -371,16 +657,16 @@ func apiRequest(url string, method string, queryParams *[]QueryParams) ([]byte,
 		req.URL.RawQuery = q.Encode()
 	}
 
-	if app.QBittorrent.BasicAuth != nil {
-		req.SetBasicAuth(app.QBittorrent.BasicAuth.Username, app.QBittorrent.BasicAuth.Password)
+	if c.settings.BasicAuth != nil {
+		req.SetBasicAuth(c.settings.BasicAuth.Username, c.settings.BasicAuth.Password)
 	}
 
-	if app.QBittorrent.APIKey != nil {
-		req.Header.Set("Authorization", "Bearer "+*app.QBittorrent.APIKey)
+	if c.settings.APIKey != nil {
+		req.Header.Set("Authorization", "Bearer "+*c.settings.APIKey)
 	} else {
 		req.AddCookie(&http.Cookie{ //nolint:exhaustruct
-			Name:     app.QBittorrent.LegacyAuth.Cookie.Key,
-			Value:    *app.QBittorrent.LegacyAuth.Cookie.Value,
+			Name:     c.settings.LegacyAuth.Cookie.Key,
+			Value:    *cookie,
 			Secure:   true,
 			HttpOnly: true,
 			SameSite: http.SameSiteStrictMode,
@@ -388,16 +674,14 @@ func apiRequest(url string, method string, queryParams *[]QueryParams) ([]byte,
 	}
 
 	logger.Trace("New request to " + req.URL.String())
-	resp, err := app.HttpClient.Do(req)
+	resp, err := c.settings.Client().Do(req)
 
 	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
-		logger.Error(API.QbittorrentTimeOut)
-
-		return nil, false, context.DeadlineExceeded
+		err = context.DeadlineExceeded
 	}
 
 	if err != nil {
-		err := fmt.Errorf("%s: %w", API.ErrorConnect, err)
+		err := &API.UnreachableError{Err: err}
 		logger.Error(err.Error())
 
 		return nil, false, err
@@ -419,17 +703,20 @@ func apiRequest(url string, method string, queryParams *[]QueryParams) ([]byte,
 
 		return body, false, nil
 	case http.StatusForbidden:
-		if app.QBittorrent.APIKey != nil {
-			err := fmt.Errorf("%d", resp.StatusCode)
+		if c.settings.APIKey != nil {
+			err := &API.AuthError{Message: "qBittorrent rejected the API key"}
 			logger.Error(fmt.Sprintf("Error code %d for: %s", resp.StatusCode, url))
 
 			return nil, false, err
 		} else {
-			err := fmt.Errorf("%d", resp.StatusCode)
+			var err error = fmt.Errorf("%d", resp.StatusCode)
 
 			logger.Warn("Cookie changed, trying to reconnect ...")
 
-			_ = Auth()
+			errAuth := c.Auth()
+			if IsAuthFailure(errAuth) {
+				return nil, false, errAuth
+			}
 
 			return nil, true, err
 		}
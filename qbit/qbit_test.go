@@ -1,6 +1,7 @@
 package qbit
 
 import (
+	"bytes"
 	"context"
 	"crypto/ecdsa"
 	"crypto/elliptic"
@@ -22,6 +23,7 @@ import (
 
 	api "qbit-exp/api"
 	app "qbit-exp/app"
+	prom "qbit-exp/prometheus"
 )
 
 var cookieKey = "SID"
@@ -29,9 +31,16 @@ var cookieValue = "random-cookie"
 
 var apikey = "apiKey"
 
+// mockTimeout leaves room for the race detector overhead.
+const mockTimeout time.Duration = 100 * time.Millisecond
+
+func newTestClient() *Client {
+	return NewClient(&app.QBittorrent)
+}
+
 func setupMockApp() {
 	app.QBittorrent.APIKey = nil
-	app.QBittorrent.Timeout = 10 * time.Millisecond
+	app.QBittorrent.Timeout = mockTimeout
 	app.QBittorrent.LegacyAuth.Cookie.Key = cookieKey
 	app.QBittorrent.LegacyAuth.Cookie.Value = &cookieValue
 }
@@ -121,9 +130,10 @@ func TestApiRequest_Success(t *testing.T) {
 	defer server.Close()
 
 	app.QBittorrent.BaseUrl = server.URL
-	url := createUrl("/test")
+	client := newTestClient()
+	url := client.createUrl("/test")
 
-	body, reAuth, err := apiRequest(url, "GET", nil)
+	body, reAuth, err := client.apiRequest(url, "GET", nil)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -147,9 +157,10 @@ func TestApiRequest_Forbidden_cookie(t *testing.T) {
 
 	app.QBittorrent.BaseUrl = server.URL
 	app.QBittorrent.LegacyAuth.Cookie.Value = &cookieValue
-	url := createUrl("/test")
+	client := newTestClient()
+	url := client.createUrl("/test")
 
-	_, reAuth, err := apiRequest(url, "GET", nil)
+	_, reAuth, err := client.apiRequest(url, "GET", nil)
 	if err == nil || err.Error() != "403" {
 		t.Fatalf("Expected error '403', got %v", err)
 	}
@@ -171,11 +182,14 @@ func TestApiRequest_Forbidden_APIKey(t *testing.T) {
 
 	app.QBittorrent.BaseUrl = server.URL
 	app.QBittorrent.LegacyAuth.Cookie.Value = &cookieValue
-	url := createUrl("/test")
+	client := newTestClient()
+	url := client.createUrl("/test")
 
-	_, reAuth, err := apiRequest(url, "GET", nil)
-	if err == nil || err.Error() != "403" {
-		t.Fatalf("Expected error '403', got %v", err)
+	_, reAuth, err := client.apiRequest(url, "GET", nil)
+
+	var authError *api.AuthError
+	if !errors.As(err, &authError) {
+		t.Fatalf("Expected an AuthError, got %v", err)
 	}
 
 	if reAuth {
@@ -187,15 +201,16 @@ func TestApiRequest_Timeout(t *testing.T) {
 	setupMockApp()
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		time.Sleep(20 * time.Millisecond)
+		time.Sleep(2 * mockTimeout)
 		w.WriteHeader(http.StatusOK)
 	}))
 	defer server.Close()
 
 	app.QBittorrent.BaseUrl = server.URL
-	url := createUrl("/test")
+	client := newTestClient()
+	url := client.createUrl("/test")
 
-	body, reAuth, err := apiRequest(url, http.MethodGet, nil)
+	body, reAuth, err := client.apiRequest(url, http.MethodGet, nil)
 	if !errors.Is(err, context.DeadlineExceeded) {
 		t.Fatalf("Expected DeadlineExceeded error, got %v", err)
 	}
@@ -223,14 +238,15 @@ func TestApiRequest_WithQueryParams(t *testing.T) {
 	defer server.Close()
 
 	app.QBittorrent.BaseUrl = server.URL
-	url := createUrl("/test")
+	client := newTestClient()
+	url := client.createUrl("/test")
 
 	queryParams := []QueryParams{
 		{"param1", "value1"},
 		{"param2", "value2"},
 	}
 
-	body, retry, err := apiRequest(url, http.MethodGet, &queryParams)
+	body, retry, err := client.apiRequest(url, http.MethodGet, &queryParams)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -253,9 +269,10 @@ func TestApiRequest_Non200Status(t *testing.T) {
 	defer server.Close()
 
 	app.QBittorrent.BaseUrl = server.URL
-	url := createUrl("/test")
+	client := newTestClient()
+	url := client.createUrl("/test")
 
-	body, retry, err := apiRequest(url, http.MethodGet, nil)
+	body, retry, err := client.apiRequest(url, http.MethodGet, nil)
 	if err == nil || err.Error() != "500" {
 		t.Fatalf("Expected error '500', got %v", err)
 	}
@@ -294,9 +311,10 @@ func TestApiRequest_WithRequestAuthorization_Success(t *testing.T) {
 		Password: httpBasicAuthPassword,
 	}
 
-	url := createUrl("/test")
+	client := newTestClient()
+	url := client.createUrl("/test")
 
-	body, retry, err := apiRequest(url, http.MethodGet, nil)
+	body, retry, err := client.apiRequest(url, http.MethodGet, nil)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -328,9 +346,10 @@ func TestApiRequest_ServerWithoutAuthRequirement(t *testing.T) {
 		Password: httpBasicAuthPassword,
 	}
 
-	url := createUrl("/test")
+	client := newTestClient()
+	url := client.createUrl("/test")
 
-	body, retry, err := apiRequest(url, http.MethodGet, nil)
+	body, retry, err := client.apiRequest(url, http.MethodGet, nil)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -361,9 +380,10 @@ func TestApiRequest_EmptyCredentials(t *testing.T) {
 		Password: httpBasicAuthPassword,
 	}
 
-	url := createUrl("/test")
+	client := newTestClient()
+	url := client.createUrl("/test")
 
-	_, retry, err := apiRequest(url, http.MethodGet, nil)
+	_, retry, err := client.apiRequest(url, http.MethodGet, nil)
 	if err == nil {
 		t.Fatalf("Expected error due to empty credentials, but got nil")
 	}
@@ -399,9 +419,10 @@ func TestApiRequest_InvalidAuthorization(t *testing.T) {
 		Password: httpBasicAuthPassword,
 	}
 
-	url := createUrl("/test")
+	client := newTestClient()
+	url := client.createUrl("/test")
 
-	_, retry, err := apiRequest(url, http.MethodGet, nil)
+	_, retry, err := client.apiRequest(url, http.MethodGet, nil)
 	if err == nil {
 		t.Fatalf("Expected error due to invalid authentication, but got nil")
 	}
@@ -438,9 +459,10 @@ func TestCustomCA(t *testing.T) {
 	}
 
 	app.QBittorrent.BaseUrl = server.URL
-	url := createUrl("/test")
+	client := newTestClient()
+	url := client.createUrl("/test")
 
-	body, retry, err := apiRequest(url, http.MethodGet, nil)
+	body, retry, err := client.apiRequest(url, http.MethodGet, nil)
 	if err != nil || retry || string(body) != "" {
 		t.Fatalf("Request failed! {body: %v, retry: %v}: %v", body, retry, err)
 	}
@@ -464,9 +486,10 @@ func TestSkipCertValidation(t *testing.T) {
 	}
 
 	app.QBittorrent.BaseUrl = server.URL
-	url := createUrl("/test")
+	client := newTestClient()
+	url := client.createUrl("/test")
 
-	body, retry, err := apiRequest(url, http.MethodGet, nil)
+	body, retry, err := client.apiRequest(url, http.MethodGet, nil)
 	if err != nil || retry || string(body) != "" {
 		t.Fatalf("Request failed! {body: %v, retry: %v}: %v", body, retry, err)
 	}
@@ -490,9 +513,10 @@ func TestMinTlsVersion(t *testing.T) {
 	}
 
 	app.QBittorrent.BaseUrl = server.URL
-	url := createUrl("/test")
+	client := newTestClient()
+	url := client.createUrl("/test")
 
-	body, retry, err := apiRequest(url, http.MethodGet, nil)
+	body, retry, err := client.apiRequest(url, http.MethodGet, nil)
 	if body != nil || retry {
 		t.Fatalf("Expected no body and no retry, got {body: %v, retry: %v}", body, retry)
 	}
@@ -524,7 +548,7 @@ func TestGetTrackersInfo_ReturnsErrorOnInvalidJSON(t *testing.T) {
 	}
 	c := make(chan func() (*api.Trackers, error), 1)
 
-	getTrackersInfo(&data, c)
+	newTestClient().getTrackersInfo(&data, c)
 
 	select {
 	case resp := <-c:
@@ -562,7 +586,7 @@ func TestGetTrackersInfo_ReturnsErrorOnAPIError(t *testing.T) {
 	}
 	c := make(chan func() (*api.Trackers, error), 1)
 
-	getTrackersInfo(&data, c)
+	newTestClient().getTrackersInfo(&data, c)
 
 	select {
 	case resp := <-c:
@@ -582,3 +606,100 @@ func TestGetTrackersInfo_ReturnsErrorOnAPIError(t *testing.T) {
 		t.Fatal("Timed out waiting for tracker response")
 	}
 }
+
+func TestSetInstancesKeepsClients(t *testing.T) { //nolint:paralleltest
+	seedbox := &app.QBittorrentSettings{Name: "seedbox", BaseUrl: "http://seedbox:8080"} //nolint:exhaustruct
+	home := &app.QBittorrentSettings{Name: "home", BaseUrl: "http://home:8080"}          //nolint:exhaustruct
+
+	SetInstances([]*app.QBittorrentSettings{seedbox, home})
+
+	defer SetInstances(nil)
+
+	seedboxClient, homeClient := clients[0], clients[1]
+
+	reloadedSeedbox := &app.QBittorrentSettings{Name: "seedbox", BaseUrl: "http://seedbox:8080", FullRefreshInterval: 10} //nolint:exhaustruct
+	movedHome := &app.QBittorrentSettings{Name: "home", BaseUrl: "http://nas:8080"}                                       //nolint:exhaustruct
+
+	SetInstances([]*app.QBittorrentSettings{reloadedSeedbox, movedHome})
+
+	if clients[0] != seedboxClient || clients[0].settings != reloadedSeedbox {
+		t.Error("expected the seedbox client to be kept with the new settings")
+	}
+
+	if clients[1] == homeClient || clients[1].settings != movedHome {
+		t.Error("expected a new client for the instance with a new URL")
+	}
+}
+
+func TestAllRequestsDown(t *testing.T) { //nolint:paralleltest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	defer SetInstances(nil)
+
+	settings := func(name string) *app.QBittorrentSettings {
+		return &app.QBittorrentSettings{Name: name, BaseUrl: server.URL, APIKey: &apikey, Timeout: mockTimeout} //nolint:exhaustruct
+	}
+
+	tests := []struct {
+		name      string
+		instances []*app.QBittorrentSettings
+		expected  []string
+	}{
+		{
+			"Single instance",
+			[]*app.QBittorrentSettings{settings("")},
+			[]string{
+				`qbittorrent_up{qbittorrent_instance="` + strings.TrimPrefix(server.URL, "http://") + `"} 0`,
+				`qbittorrent_exporter_request_errors_total{endpoint="app/webapiVersion",qbittorrent_instance="` + strings.TrimPrefix(server.URL, "http://") + `"} 1`,
+			},
+		},
+		{
+			"Multiple instances",
+			[]*app.QBittorrentSettings{settings("seedbox"), settings("home")},
+			[]string{`qbittorrent_up{qbittorrent_instance="seedbox"} 0`, `qbittorrent_up{qbittorrent_instance="home"} 0`},
+		},
+	}
+
+	for _, tt := range tests {
+		SetInstances(tt.instances)
+
+		r := prom.NewRegistry(nil)
+
+		err := AllRequests(r)
+		if err != nil {
+			t.Fatalf("%s: expected qBittorrent to be reported down, got %v", tt.name, err)
+		}
+
+		var output bytes.Buffer
+
+		r.WritePrometheus(&output)
+
+		for _, expected := range tt.expected {
+			if !strings.Contains(output.String(), expected) {
+				t.Errorf("%s: expected %s in\n%s", tt.name, expected, output.String())
+			}
+		}
+	}
+}
+
+func TestInstanceLabels(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		baseUrl  string
+		expected string
+	}{
+		{"seedbox", "http://seedbox:8080", "seedbox"},
+		{"", "http://192.168.1.10:8080", "192.168.1.10:8080"},
+		{"", "https://qbittorrent.example/", "qbittorrent.example"},
+	}
+
+	for _, tt := range tests {
+		labels := instanceLabels(tt.name, tt.baseUrl)
+		if labels[prom.LabelInstance] != tt.expected {
+			t.Errorf("%s %s: expected %s, got %s", tt.name, tt.baseUrl, tt.expected, labels[prom.LabelInstance])
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package qbit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	API "qbit-exp/api"
+	"qbit-exp/logger"
+	prom "qbit-exp/prometheus"
+)
+
+// rssPathSeparator separates the folders in the path of RSS items.
+const rssPathSeparator string = `\`
+
+// getRSS collects the RSS feeds and auto-downloading rules. An error doesn't
+// fail the scrape.
+func (c *Client) getRSS(r *prom.Registry) {
+	var items API.RSSItems
+
+	err := c.fetchRSS("rss/items", &[]QueryParams{{Key: "withData", Value: "true"}}, &items)
+	if err != nil {
+		logger.Warn(fmt.Sprintf("Can't get the RSS feeds: %s", err))
+
+		return
+	}
+
+	feeds, err := flattenRSSItems(items, "")
+	if err != nil {
+		logger.Warn(fmt.Sprintf("Can't read the RSS feeds: %s", err))
+
+		return
+	}
+
+	var rules map[string]API.RSSRule
+
+	err = c.fetchRSS("rss/rules", nil, &rules)
+	if err != nil {
+		logger.Warn(fmt.Sprintf("Can't get the RSS rules: %s", err))
+
+		return
+	}
+
+	prom.RSS(feeds, rules, r)
+}
+
+// fetchRSS fetches an RSS endpoint into result.
+func (c *Client) fetchRSS(path string, queryParams *[]QueryParams, result any) error {
+	url := c.createUrl(baseAPIRUL + path)
+
+	body, retry, err := c.apiRequest(url, http.MethodGet, queryParams)
+	if retry {
+		logger.Debug("Retrying RSS request...")
+
+		body, _, err = c.apiRequest(url, http.MethodGet, queryParams)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(body, result)
+	if err != nil {
+		errMsg := fmt.Errorf("%s %s: %w", unmarshError, url, err)
+		errorHelper(&body, &errMsg, &url)
+
+		return err
+	}
+
+	return nil
+}
+
+// flattenRSSItems returns the feeds of a folder and of its subfolders, sorted
+// by path. Feeds are told apart from folders by their URL.
+func flattenRSSItems(items API.RSSItems, folder string) ([]prom.RSSFeed, error) {
+	feeds := []prom.RSSFeed{}
+
+	for name, item := range items {
+		path := name
+		if folder != "" {
+			path = folder + rssPathSeparator + name
+		}
+
+		var feed API.RSSFeed
+
+		err := json.Unmarshal(item, &feed)
+		if err != nil {
+			return nil, err
+		}
+
+		if feed.URL != "" {
+			feeds = append(feeds, prom.RSSFeed{Path: path, Feed: feed})
+
+			continue
+		}
+
+		var subfolder API.RSSItems
+
+		err = json.Unmarshal(item, &subfolder)
+		if err != nil {
+			return nil, err
+		}
+
+		subfeeds, err := flattenRSSItems(subfolder, path)
+		if err != nil {
+			return nil, err
+		}
+
+		feeds = append(feeds, subfeeds...)
+	}
+
+	slices.SortFunc(feeds, func(a, b prom.RSSFeed) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	return feeds, nil
+}
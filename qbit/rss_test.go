@@ -0,0 +1,73 @@
+package qbit
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	API "qbit-exp/api"
+	prom "qbit-exp/prometheus"
+)
+
+func TestFlattenRSSItems(t *testing.T) {
+	t.Parallel()
+
+	items := API.RSSItems{
+		"Top":   []byte(`{"uid":"1","url":"https://example.com/top","articles":[]}`),
+		"Empty": []byte(`{}`),
+		"Linux": []byte(`{"Distros":{"uid":"2","url":"https://example.com/distros"},"Kernel":{"News":{"uid":"3","url":"https://example.com/kernel"}}}`),
+	}
+
+	feeds, err := flattenRSSItems(items, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	paths := make([]string, 0, len(feeds))
+	for _, feed := range feeds {
+		paths = append(paths, feed.Path)
+	}
+
+	if strings.Join(paths, ",") != `Linux\Distros,Linux\Kernel\News,Top` {
+		t.Errorf("unexpected feeds %v", paths)
+	}
+}
+
+func TestGetRSS(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/rss/items":
+			if r.URL.Query().Get("withData") != "true" {
+				t.Errorf("expected the feeds to be requested with their data, got %s", r.URL)
+			}
+
+			_, _ = w.Write([]byte(`{"News":{"uid":"1","url":"https://example.com/rss","hasError":false,"articles":[{"id":"1","isRead":false}]}}`))
+		case "/api/v2/rss/rules":
+			_, _ = w.Write([]byte(`{"Rule":{"enabled":true,"affectedFeeds":["https://example.com/rss"]}}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+		}
+	}))
+	defer server.Close()
+
+	registry := prom.NewRegistry(nil)
+
+	newFakeClient(server.URL).getRSS(registry)
+
+	var output bytes.Buffer
+
+	registry.WritePrometheus(&output)
+
+	for _, expected := range []string{
+		`qbittorrent_rss_feed_unread_articles{feed="News"} 1`,
+		`qbittorrent_rss_rules_enabled 1`,
+	} {
+		if !strings.Contains(output.String(), expected+"\n") {
+			t.Errorf("expected %s in\n%s", expected, output.String())
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package qbit
+
+import (
+	"maps"
+	"strings"
+	"sync"
+	"time"
+
+	prom "qbit-exp/prometheus"
+)
+
+// scrapeStats gathers the statistics of the collections of an instance.
+// Requests run concurrently during a collection.
+type scrapeStats struct {
+	mu sync.Mutex
+
+	duration    time.Duration
+	lastSuccess time.Time
+
+	// requests are the statistics by API endpoint. Their duration is reset
+	// at the start of each collection.
+	requests map[string]prom.RequestStats
+
+	fullSyncs  uint64
+	deltaSyncs uint64
+}
+
+// startCollection resets the durations of the requests.
+func (s *scrapeStats) startCollection() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for endpoint, request := range s.requests {
+		request.Duration = 0
+		s.requests[endpoint] = request
+	}
+}
+
+// endCollection records the duration and the result of a collection.
+func (s *scrapeStats) endCollection(start time.Time, success bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.duration = time.Since(start)
+
+	if success {
+		s.lastSuccess = start
+	}
+}
+
+// observeRequest records a request to the API endpoint.
+func (s *scrapeStats) observeRequest(endpoint string, duration time.Duration, failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.requests == nil {
+		s.requests = make(map[string]prom.RequestStats)
+	}
+
+	request := s.requests[endpoint]
+	request.Duration += duration
+	request.Requests++
+
+	if failed {
+		request.Errors++
+	}
+
+	s.requests[endpoint] = request
+}
+
+// observeSync records a sync/maindata response.
+func (s *scrapeStats) observeSync(full bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if full {
+		s.fullSyncs++
+	} else {
+		s.deltaSyncs++
+	}
+}
+
+// get returns a copy of the statistics, with the number of torrents in the
+// delta sync state.
+func (s *scrapeStats) get(torrents int) *prom.ScrapeStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return &prom.ScrapeStats{
+		Duration:    s.duration,
+		LastSuccess: s.lastSuccess,
+		Requests:    maps.Clone(s.requests),
+		FullSyncs:   s.fullSyncs,
+		DeltaSyncs:  s.deltaSyncs,
+		Torrents:    torrents,
+	}
+}
+
+// apiEndpoint returns the endpoint of the URL of an API request, e.g.
+// "sync/maindata".
+func apiEndpoint(url string) string {
+	_, endpoint, found := strings.Cut(url, baseAPIRUL)
+	if !found {
+		return url
+	}
+
+	return endpoint
+}
@@ -0,0 +1,58 @@
+package qbit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScrapeStats(t *testing.T) {
+	t.Parallel()
+
+	var stats scrapeStats
+
+	start := time.Now()
+
+	stats.startCollection()
+	stats.observeRequest("sync/maindata", time.Second, false)
+	stats.observeRequest("torrents/trackers", time.Second, true)
+	stats.observeRequest("torrents/trackers", time.Second, false)
+	stats.observeSync(true)
+	stats.endCollection(start, true)
+
+	stats.startCollection()
+	stats.observeRequest("sync/maindata", 2*time.Second, true)
+	stats.observeSync(false)
+	stats.endCollection(time.Now(), false)
+
+	got := stats.get(3)
+
+	if !got.LastSuccess.Equal(start) {
+		t.Errorf("expected the last success at %s, got %s", start, got.LastSuccess)
+	}
+
+	if got.FullSyncs != 1 || got.DeltaSyncs != 1 || got.Torrents != 3 {
+		t.Errorf("unexpected syncs %+v", got)
+	}
+
+	if maindata := got.Requests["sync/maindata"]; maindata.Duration != 2*time.Second || maindata.Requests != 2 || maindata.Errors != 1 {
+		t.Errorf("unexpected maindata requests %+v", maindata)
+	}
+
+	if trackers := got.Requests["torrents/trackers"]; trackers.Duration != 0 || trackers.Requests != 2 || trackers.Errors != 1 {
+		t.Errorf("expected the duration of the trackers requests to be reset, got %+v", trackers)
+	}
+}
+
+func TestApiEndpoint(t *testing.T) {
+	t.Parallel()
+
+	for url, expected := range map[string]string{
+		"http://localhost:8080/api/v2/sync/maindata":  "sync/maindata",
+		"https://seedbox/qbit/api/v2/app/preferences": "app/preferences",
+		"/unknown": "/unknown",
+	} {
+		if got := apiEndpoint(url); got != expected {
+			t.Errorf("apiEndpoint(%s) = %s, want %s", url, got, expected)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package qbit
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	API "qbit-exp/api"
+	"qbit-exp/app"
+)
+
+// topTorrents splits the torrents into the count first torrents ranked by the
+// key of settings, in descending order, and the other torrents.
+func topTorrents(torrents API.SliceInfo, settings *app.TopTorrentsSettings) (API.SliceInfo, API.SliceInfo) {
+	if len(torrents) <= settings.Count {
+		return torrents, nil
+	}
+
+	key := topTorrentsKey(settings.By)
+	sorted := slices.Clone(torrents)
+
+	slices.SortFunc(sorted, func(a, b API.Info) int {
+		return cmp.Or(
+			cmp.Compare(key(b), key(a)),
+			strings.Compare(a.Hash, b.Hash),
+		)
+	})
+
+	return sorted[:settings.Count], sorted[settings.Count:]
+}
+
+func topTorrentsKey(by string) func(API.Info) float64 {
+	switch by {
+	case app.TopByDownloadSpeed:
+		return func(t API.Info) float64 { return float64(t.Dlspeed) }
+	case app.TopByRatio:
+		return func(t API.Info) float64 { return t.Ratio }
+	case app.TopBySize:
+		return func(t API.Info) float64 { return float64(t.Size) }
+	case app.TopByAddedOn:
+		return func(t API.Info) float64 { return float64(t.AddedOn) }
+	default:
+		return func(t API.Info) float64 { return float64(t.Upspeed) }
+	}
+}
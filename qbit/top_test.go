@@ -0,0 +1,57 @@
+package qbit
+
+import (
+	"slices"
+	"testing"
+
+	API "qbit-exp/api"
+	"qbit-exp/app"
+)
+
+func TestTopTorrents(t *testing.T) {
+	t.Parallel()
+
+	torrents := API.SliceInfo{
+		{Hash: "a", Upspeed: 10, Dlspeed: 0, Ratio: 3, Size: 100, AddedOn: 1},   //nolint:exhaustruct
+		{Hash: "b", Upspeed: 30, Dlspeed: 5, Ratio: 1, Size: 300, AddedOn: 3},   //nolint:exhaustruct
+		{Hash: "c", Upspeed: 20, Dlspeed: 50, Ratio: 2, Size: 200, AddedOn: 2},  //nolint:exhaustruct
+		{Hash: "d", Upspeed: 20, Dlspeed: 10, Ratio: 0.5, Size: 50, AddedOn: 4}, //nolint:exhaustruct
+	}
+
+	tests := []struct {
+		by          string
+		expectedTop []string
+	}{
+		{app.TopByUploadSpeed, []string{"b", "c"}},
+		{app.TopByDownloadSpeed, []string{"c", "d"}},
+		{app.TopByRatio, []string{"a", "c"}},
+		{app.TopBySize, []string{"b", "c"}},
+		{app.TopByAddedOn, []string{"d", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.by, func(t *testing.T) {
+			t.Parallel()
+
+			top, others := topTorrents(torrents, &app.TopTorrentsSettings{Count: 2, By: tt.by})
+
+			hashes := make([]string, 0, len(top))
+			for _, torrent := range top {
+				hashes = append(hashes, torrent.Hash)
+			}
+
+			if !slices.Equal(hashes, tt.expectedTop) {
+				t.Errorf("expected top %v, got %v", tt.expectedTop, hashes)
+			}
+
+			if len(others) != 2 {
+				t.Errorf("expected 2 other torrents, got %d", len(others))
+			}
+		})
+	}
+
+	top, others := topTorrents(torrents, &app.TopTorrentsSettings{Count: 10, By: app.TopByUploadSpeed})
+	if len(top) != len(torrents) || len(others) != 0 {
+		t.Errorf("expected every torrent in the top, got %d and %d others", len(top), len(others))
+	}
+}
@@ -0,0 +1,77 @@
+package qbit
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"sync"
+
+	API "qbit-exp/api"
+)
+
+// maxTorrentRequests limits the number of concurrent per-torrent requests.
+const maxTorrentRequests int = 8
+
+// selectTorrents returns the torrents whose name or hash is listed in
+// selection. Without selection, the torrents matching byDefault are used.
+func selectTorrents(torrents API.SliceInfo, selection []string, byDefault func(API.Info) bool) API.SliceInfo {
+	var selected API.SliceInfo
+
+	for _, torrent := range torrents {
+		if len(selection) == 0 {
+			if byDefault(torrent) {
+				selected = append(selected, torrent)
+			}
+
+			continue
+		}
+
+		if slices.Contains(selection, torrent.Hash) || slices.Contains(selection, torrent.Name) {
+			selected = append(selected, torrent)
+		}
+	}
+
+	return selected
+}
+
+// limitTorrents returns at most maxTorrents torrents, the transferring ones
+// first and then the most recently added.
+func limitTorrents(torrents API.SliceInfo, maxTorrents int) API.SliceInfo {
+	if len(torrents) <= maxTorrents {
+		return torrents
+	}
+
+	sorted := slices.Clone(torrents)
+
+	slices.SortFunc(sorted, func(a, b API.Info) int {
+		return cmp.Or(
+			cmp.Compare(b.Dlspeed+b.Upspeed, a.Dlspeed+a.Upspeed),
+			cmp.Compare(b.AddedOn, a.AddedOn),
+			strings.Compare(a.Hash, b.Hash),
+		)
+	})
+
+	return sorted[:maxTorrents]
+}
+
+// forEachTorrent calls fn for each torrent, with at most maxTorrentRequests
+// calls running at the same time, and waits for all of them.
+func forEachTorrent(torrents API.SliceInfo, fn func(i int, torrent API.Info)) {
+	var wg sync.WaitGroup
+
+	semaphore := make(chan struct{}, maxTorrentRequests)
+
+	for i, torrent := range torrents {
+		// Acquired before starting the goroutine, so that at most
+		// maxTorrentRequests goroutines exist at the same time
+		semaphore <- struct{}{}
+
+		wg.Go(func() {
+			defer func() { <-semaphore }()
+
+			fn(i, torrent)
+		})
+	}
+
+	wg.Wait()
+}
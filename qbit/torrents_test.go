@@ -0,0 +1,88 @@
+package qbit
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	API "qbit-exp/api"
+)
+
+func TestSelectTorrents(t *testing.T) {
+	t.Parallel()
+
+	torrents := API.SliceInfo{
+		{Name: "active", Hash: "hash1", Upspeed: 10},     //nolint:exhaustruct
+		{Name: "idle", Hash: "hash2"},                    //nolint:exhaustruct
+		{Name: "downloading", Hash: "hash3", Dlspeed: 5}, //nolint:exhaustruct
+	}
+
+	selected := selectTorrents(torrents, nil, isTransferring)
+	if len(selected) != 2 || selected[0].Hash != "hash1" || selected[1].Hash != "hash3" {
+		t.Errorf("expected the active torrents, got %+v", selected)
+	}
+
+	selected = selectTorrents(torrents, []string{"idle", "hash3"}, isTransferring)
+	if len(selected) != 2 || selected[0].Hash != "hash2" || selected[1].Hash != "hash3" {
+		t.Errorf("expected the torrents selected by name or hash, got %+v", selected)
+	}
+}
+
+func TestForEachTorrent(t *testing.T) {
+	t.Parallel()
+
+	torrents := make(API.SliceInfo, 3*maxTorrentRequests)
+	visited := make([]bool, len(torrents))
+
+	forEachTorrent(torrents, func(i int, _ API.Info) {
+		visited[i] = true
+	})
+
+	for i, ok := range visited {
+		if !ok {
+			t.Errorf("torrent %d was not visited", i)
+		}
+	}
+}
+
+func TestForEachTorrentBoundsGoroutines(t *testing.T) { //nolint:paralleltest
+	torrents := make(API.SliceInfo, 100*maxTorrentRequests)
+	baseline := runtime.NumGoroutine()
+
+	var mu sync.Mutex
+
+	maxGoroutines := 0
+
+	forEachTorrent(torrents, func(int, API.Info) {
+		mu.Lock()
+		maxGoroutines = max(maxGoroutines, runtime.NumGoroutine()-baseline)
+		mu.Unlock()
+
+		time.Sleep(time.Millisecond)
+	})
+
+	// The other tests can start a few goroutines meanwhile
+	if maxGoroutines > 2*maxTorrentRequests {
+		t.Errorf("expected at most %d goroutines, got %d", maxTorrentRequests, maxGoroutines)
+	}
+}
+
+func TestLimitTorrents(t *testing.T) {
+	t.Parallel()
+
+	torrents := API.SliceInfo{
+		{Hash: "old", AddedOn: 1},                //nolint:exhaustruct
+		{Hash: "new", AddedOn: 3},                //nolint:exhaustruct
+		{Hash: "active", AddedOn: 2, Upspeed: 1}, //nolint:exhaustruct
+	}
+
+	got := limitTorrents(torrents, 2)
+	if len(got) != 2 || got[0].Hash != "active" || got[1].Hash != "new" {
+		t.Errorf("expected the active and newest torrents, got %+v", got)
+	}
+
+	if got := limitTorrents(torrents, 3); len(got) != 3 || got[0].Hash != "old" {
+		t.Errorf("expected the torrents to be kept under the limit, got %+v", got)
+	}
+}
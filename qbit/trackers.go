@@ -0,0 +1,75 @@
+package qbit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	API "qbit-exp/api"
+	"qbit-exp/logger"
+	prom "qbit-exp/prometheus"
+)
+
+// getTorrentsTrackers collects the trackers of every torrent, to count the
+// torrents of each tracker by tracker status. The trackers are returned for
+// the tracker host aggregates.
+func (c *Client) getTorrentsTrackers(torrents API.SliceInfo, r *prom.Registry) []*API.Trackers {
+	results := make([]*API.Trackers, len(torrents))
+
+	forEachTorrent(torrents, func(i int, torrent API.Info) {
+		trackers, err := c.fetchTorrentTrackers(torrent.Hash)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Can't get the trackers of %s: %s", torrent.Name, err))
+
+			return
+		}
+
+		results[i] = trackers
+	})
+
+	responses := make([]*API.Trackers, 0, len(results))
+
+	for _, result := range results {
+		if result != nil {
+			responses = append(responses, result)
+		}
+	}
+
+	prom.Trackers(responses, r)
+	prom.TrackerTorrents(responses, r)
+
+	return responses
+}
+
+// fetchTorrentTrackers fetches the trackers of a torrent from
+// torrents/trackers.
+func (c *Client) fetchTorrentTrackers(hash string) (*API.Trackers, error) {
+	url := c.createUrl(baseAPIRUL + "torrents/trackers")
+
+	queryParams := &[]QueryParams{
+		{Key: "hash", Value: hash},
+	}
+
+	body, retry, err := c.apiRequest(url, http.MethodGet, queryParams)
+	if retry {
+		logger.Debug("Retrying torrent trackers request...")
+
+		body, _, err = c.apiRequest(url, http.MethodGet, queryParams)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	trackers := new(API.Trackers)
+
+	err = json.Unmarshal(body, trackers)
+	if err != nil {
+		errMsg := fmt.Errorf("%s %s: %w", unmarshError, url, err)
+		errorHelper(&body, &errMsg, &url)
+
+		return nil, err
+	}
+
+	return trackers, nil
+}
@@ -0,0 +1,57 @@
+package qbit
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	API "qbit-exp/api"
+	prom "qbit-exp/prometheus"
+)
+
+func TestGetTorrentsTrackers(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/torrents/trackers" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+
+		status := "2"
+		if r.URL.Query().Get("hash") == "b" {
+			status = "4"
+		}
+
+		_, _ = w.Write([]byte(`[{"url":"** [DHT] **","status":2,"tier":""},{"url":"http://tracker.example/announce","status":` + status + `,"tier":0}]`))
+	}))
+	defer server.Close()
+
+	registry := prom.NewRegistry(nil)
+
+	torrents := API.SliceInfo{
+		{Hash: "a", Name: "a", Tracker: "http://tracker.example/announce"}, //nolint:exhaustruct
+		{Hash: "b", Name: "b", Tracker: "http://tracker.example/announce"}, //nolint:exhaustruct
+		{Hash: "c", Name: "c", Tracker: "http://tracker.example/announce"}, //nolint:exhaustruct
+	}
+
+	statuses := newFakeClient(server.URL).getTorrentsTrackers(torrents, registry)
+	if len(statuses) != len(torrents) {
+		t.Errorf("expected the trackers of %d torrents, got %d", len(torrents), len(statuses))
+	}
+
+	var output bytes.Buffer
+
+	registry.WritePrometheus(&output)
+
+	for _, expected := range []string{
+		`qbittorrent_tracker_torrents{state="working",url="http://tracker.example/announce"} 2`,
+		`qbittorrent_tracker_torrents{state="not_working",url="http://tracker.example/announce"} 1`,
+		`qbittorrent_tracker_torrents{state="working",url="dht"} 3`,
+	} {
+		if !strings.Contains(output.String(), expected+"\n") {
+			t.Errorf("expected %s in\n%s", expected, output.String())
+		}
+	}
+}
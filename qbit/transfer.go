@@ -0,0 +1,73 @@
+package qbit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	API "qbit-exp/api"
+	"qbit-exp/internal"
+	"qbit-exp/logger"
+	prom "qbit-exp/prometheus"
+)
+
+// serverStateTransferVersion is the first Web API version whose sync/maindata
+// server_state has the transfer/info fields.
+const serverStateTransferVersion string = "2.2.0"
+
+// getTransferInfo fetches transfer/info to export the live rate limits and to
+// complete serverState when the Web API doesn't send these fields. An error
+// doesn't fail the scrape, since server_state has most of the values.
+func (c *Client) getTransferInfo(serverState *API.ServerState, webUIVersion string, r *prom.Registry) {
+	url := c.createUrl(baseAPIRUL + "transfer/info")
+
+	body, retry, err := c.apiRequest(url, http.MethodGet, nil)
+	if retry {
+		logger.Debug("Retrying transfer info request...")
+
+		body, _, err = c.apiRequest(url, http.MethodGet, nil)
+	}
+
+	if err != nil {
+		logger.Warn(fmt.Sprintf("Can't get the transfer info: %s", err))
+
+		return
+	}
+
+	var transfer API.TransferInfo
+
+	err = json.Unmarshal(body, &transfer)
+	if err != nil {
+		errMsg := fmt.Errorf("%s %s: %w", unmarshError, url, err)
+		errorHelper(&body, &errMsg, &url)
+
+		return
+	}
+
+	mergeTransferInfo(serverState, &transfer, webUIVersion)
+
+	prom.Transfer(&transfer, r)
+}
+
+// mergeTransferInfo copies the transfer info into serverState when the Web API
+// is older than serverStateTransferVersion or didn't send the connection
+// status. Otherwise, differences between both are logged.
+func mergeTransferInfo(serverState *API.ServerState, transfer *API.TransferInfo, webUIVersion string) {
+	if internal.CompareSemVer(webUIVersion, serverStateTransferVersion) == -1 || serverState.ConnectionStatus == "" {
+		logger.Trace("Using transfer/info for the server state")
+
+		serverState.ConnectionStatus = transfer.ConnectionStatus
+		serverState.DHTNodes = transfer.DHTNodes
+		serverState.DlInfoData = transfer.DlInfoData
+		serverState.DlInfoSpeed = transfer.DlInfoSpeed
+		serverState.UpInfoData = transfer.UpInfoData
+		serverState.UpInfoSpeed = transfer.UpInfoSpeed
+
+		return
+	}
+
+	if serverState.ConnectionStatus != transfer.ConnectionStatus || serverState.DHTNodes != transfer.DHTNodes {
+		logger.Debug(fmt.Sprintf("server_state and transfer/info differ: connection status %s/%s, DHT nodes %d/%d",
+			serverState.ConnectionStatus, transfer.ConnectionStatus, serverState.DHTNodes, transfer.DHTNodes))
+	}
+}
@@ -0,0 +1,114 @@
+package qbit
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	API "qbit-exp/api"
+	prom "qbit-exp/prometheus"
+)
+
+func TestMergeTransferInfo(t *testing.T) {
+	t.Parallel()
+
+	transfer := API.TransferInfo{ //nolint:exhaustruct
+		ConnectionStatus: "firewalled",
+		DHTNodes:         300,
+		DlInfoSpeed:      1000,
+	}
+
+	tests := []struct {
+		name               string
+		webUIVersion       string
+		serverState        API.ServerState
+		expectedStatus     string
+		expectedDHTNodes   int64
+		expectedDlInfoData int64
+	}{
+		{
+			"Old Web API",
+			"2.1.1",
+			API.ServerState{ConnectionStatus: "connected", DHTNodes: 5, DlInfoData: 7}, //nolint:exhaustruct
+			"firewalled", 300, 0,
+		},
+		{
+			"Missing connection status",
+			"2.11.2",
+			API.ServerState{DHTNodes: 5}, //nolint:exhaustruct
+			"firewalled", 300, 0,
+		},
+		{
+			"Recent Web API",
+			"2.11.2",
+			API.ServerState{ConnectionStatus: "connected", DHTNodes: 5, DlInfoData: 7}, //nolint:exhaustruct
+			"connected", 5, 7,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			serverState := tt.serverState
+			mergeTransferInfo(&serverState, &transfer, tt.webUIVersion)
+
+			if serverState.ConnectionStatus != tt.expectedStatus || serverState.DHTNodes != tt.expectedDHTNodes || serverState.DlInfoData != tt.expectedDlInfoData {
+				t.Errorf("unexpected server state %+v", serverState)
+			}
+		})
+	}
+}
+
+func TestGetTransferInfo(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/transfer/info" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+
+		_, _ = w.Write([]byte(`{"connection_status":"connected","dht_nodes":42,"dl_rate_limit":1024,"up_rate_limit":512}`))
+	}))
+	defer server.Close()
+
+	registry := prom.NewRegistry(nil)
+	serverState := API.ServerState{} //nolint:exhaustruct
+
+	newFakeClient(server.URL).getTransferInfo(&serverState, "2.0.0", registry)
+
+	if serverState.DHTNodes != 42 || serverState.ConnectionStatus != "connected" {
+		t.Errorf("expected the server state to be completed, got %+v", serverState)
+	}
+
+	var output bytes.Buffer
+
+	registry.WritePrometheus(&output)
+
+	for _, expected := range []string{
+		"qbittorrent_transfer_download_rate_limit_bytes 1024\n",
+		"qbittorrent_transfer_upload_rate_limit_bytes 512\n",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("expected %s in\n%s", expected, output.String())
+		}
+	}
+}
+
+func TestGetTransferInfoUnavailable(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	registry := prom.NewRegistry(nil)
+	serverState := API.ServerState{ConnectionStatus: "connected"} //nolint:exhaustruct
+
+	newFakeClient(server.URL).getTransferInfo(&serverState, "2.11.2", registry)
+
+	if serverState.ConnectionStatus != "connected" || len(registry.ListMetricNames()) != 0 {
+		t.Errorf("expected the server state to be kept without transfer info")
+	}
+}